@@ -15,39 +15,58 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/auth/generate-token": {
-            "post": {
-                "description": "Generates a new JWT (or other) token for user authentication",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns JSON Web Key Set (RFC 7517) with public keys of asymmetric algorithms. For HS256 set is empty - such tokens can be checked only through validate-token",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Authentication"
                 ],
-                "summary": "Generate a new authentication token",
-                "parameters": [
-                    {
-                        "description": "Token generation parameters",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                "summary": "Public keys for verifying tokens locally",
+                "responses": {
+                    "200": {
+                        "description": "Set of public keys",
                         "schema": {
-                            "$ref": "#/definitions/dtos.GenerateTokenRequest"
+                            "$ref": "#/definitions/services.JsonWebKeySet"
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/admin/invite-codes": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Getting all invite codes (without codes themselves)",
                 "responses": {
                     "200": {
-                        "description": "Successfully generated token",
+                        "description": "Invite codes, newest first",
                         "schema": {
-                            "$ref": "#/definitions/dtos.TokenResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.InviteCodeDto"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Invalid UserId format (must be UUID)",
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorDto"
                         }
@@ -59,10 +78,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/auth/register": {
+            },
             "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Code is returned only in this response, only its hash is stored",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,26 +93,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Create user entity in database, making him ready to log in",
+                "summary": "Creating invite code for registration with role",
                 "parameters": [
                     {
-                        "description": "Register parameters",
+                        "description": "Dto with role, limit of uses and lifetime of code",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.RegisterRequest"
+                            "$ref": "#/definitions/dtos.CreateInviteCodeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Successfully created user in db"
+                    "201": {
+                        "description": "Created code",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CreateInviteCodeResponse"
+                        }
                     },
                     "400": {
-                        "description": "Invalid role",
+                        "description": "Role doesn't exist (error_code: role_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorDto"
                         }
@@ -103,35 +141,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/send-sms-code": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+        "/api/v1/admin/invite-codes/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Sending sms-code to user to phone number he entered",
+                "summary": "Revoking invite code, so it can't be used anymore",
                 "parameters": [
                     {
-                        "description": "Dto with phone number",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Id of invite code",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Code is revoked"
+                    },
+                    "400": {
+                        "description": "Invalid id of code",
                         "schema": {
-                            "$ref": "#/definitions/dtos.SendSmsCodeRequest"
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "Code doesn't exist or is already revoked",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/admin/keys/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Old key stays valid for verification until tokens signed by it expire. Available only when keys are managed by service (see tokens.key_rotation_interval_hours)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
                 ],
+                "summary": "Replacing current signing key with new one",
                 "responses": {
                     "200": {
-                        "description": "Successfully sent code"
+                        "description": "Id of new signing key",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RotateKeyResponse"
+                        }
                     },
-                    "400": {
-                        "description": "Invalid phone number",
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "Signing key is taken from config (error_code: key_rotation_disabled)",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorDto"
                         }
@@ -145,9 +247,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/validate-token": {
+        "/api/v1/admin/roles": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "All roles with their permissions",
+                "responses": {
+                    "200": {
+                        "description": "Roles sorted by name",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.RoleDto"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "It checks if token is valid and not tried to be changed",
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -155,32 +298,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Checking if authentication token is valid",
+                "summary": "Creating new role",
                 "parameters": [
                     {
-                        "description": "Dto containing token (format of JWT-token)",
+                        "description": "Dto with definition of role",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.ValidateTokenRequest"
+                            "$ref": "#/definitions/dtos.CreateRoleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Dto with field 'is_valid' that shows if token is valid",
+                    "201": {
+                        "description": "Created role",
                         "schema": {
-                            "$ref": "#/definitions/dtos.ValidateTokenResponse"
+                            "$ref": "#/definitions/dtos.RoleDto"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name, description, permissions or registration policy",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "Role already exists (error_code: role_exists)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
                         }
                     }
                 }
             }
         },
-        "/api/v1/auth/verify-sms-code": {
-            "post": {
+        "/api/v1/admin/roles/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Changed permissions get into tokens issued after change",
                 "consumes": [
                     "application/json"
                 ],
@@ -188,26 +367,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Verifying SMS code if it is what was sent to user",
+                "summary": "Replacing definition of role",
                 "parameters": [
                     {
-                        "description": "Dto with phone number and SMS code",
+                        "type": "string",
+                        "description": "Name of role",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dto with new definition of role",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.VerifySmsCodeRequest"
+                            "$ref": "#/definitions/dtos.UpdateRoleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Valid SMS code, giving token"
+                        "description": "Changed role",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RoleDto"
+                        }
                     },
                     "400": {
-                        "description": "Invalid SMS code",
+                        "description": "Invalid description, permissions or registration policy",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "Role doesn't exist",
                         "schema": {
                             "$ref": "#/definitions/dtos.ErrorDto"
                         }
@@ -219,51 +426,2057 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/healthz": {
-            "get": {
-                "description": "Returns 200 if the service is healthy and ready to accept traffic",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
                 ],
+                "description": "Role which is primary role of some user can't be deleted. Users who have role as additional one lose it",
                 "tags": [
-                    "Infrastructure"
+                    "Admin"
+                ],
+                "summary": "Deleting role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of role",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check endpoint for Kubernetes",
                 "responses": {
-                    "200": {
-                        "description": "Service is healthy",
+                    "204": {
+                        "description": "Role is deleted"
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "Role doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "Role is primary role of some users (error_code: role_in_use) or built-in (role_built_in)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dtos.ErrorDto": {
-            "type": "object",
-            "properties": {
-                "error_message": {
-                    "type": "string"
+        },
+        "/api/v1/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Users are sorted from newest. Deleted users are listed until they are purged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Page of users with optional filtering",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of page, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of page, from 1 to 100 (20 by default)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Primary or additional role of users",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status of users (active, suspended, banned, deleted)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Beginning of phone number in international format, e.g. +7912",
+                        "name": "phone_prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of users",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UsersPageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters of request",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "User with passed id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Same as deletion of account by user: user is marked deleted, its sessions are revoked and data is purged after grace period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Deleting user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User is deleted, giving moment of purge",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeleteAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user or admin tries to delete own account (error_code: cannot_modify_own_account)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Approving user who registered with role requiring approval",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/ban": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "All sessions of user are revoked, banned user can't log in until unbanned. Phone number of banned user can't be used for new account",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Banning user for abuse",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Banned user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user or admin tries to ban own account (error_code: cannot_modify_own_account)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "All sessions of user are revoked, so new role is applied at next login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Changing primary role of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dto with new role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ChangeRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid role or admin tries to change own role (error_code: cannot_modify_own_account)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Primary and additional roles of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Roles of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserRolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Primary role is changed separately. All sessions of user are revoked, so new roles are applied at next login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Replacing additional roles of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dto with all additional roles of user",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SetUserRolesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Roles of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserRolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Role doesn't exist (error_code: role_not_found) or admin tries to change own roles (cannot_modify_own_account)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "All sessions of user are revoked, suspended user can't log in until unsuspended",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Suspending user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suspended user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user or admin tries to suspend own account (error_code: cannot_modify_own_account)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/unban": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Lifting ban of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/unsuspend": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Lifting suspension of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of user",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User is deleted (error_code: account_deleted)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/generate-token": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Only admin can call it. Role of token is taken from user, so token gives no more rights than user has",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Generate a new authentication token for existing user (for developers)",
+                "parameters": [
+                    {
+                        "description": "Token generation parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.GenerateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully generated token",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UserId format (must be UUID)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Token doesn't belong to admin (error_code: forbidden) or user can't log in (account_deleted, account_suspended, account_banned, account_pending_approval)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/introspect": {
+            "post": {
+                "security": [
+                    {
+                        "ClientBasic": []
+                    }
+                ],
+                "description": "For valid access token returns \"active\": true with its claims, for any invalid, expired or revoked token - only \"active\": false.\nCaller authenticates as one of tokens.introspection_clients with HTTP Basic (client id and secret)",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Returning state and claims of token (RFC 7662)",
+                "parameters": [
+                    {
+                        "description": "Dto with token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.IntrospectTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "State and claims of token",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.IntrospectTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Token is empty",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Client credentials are missing or invalid (error_code: invalid_client)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/login/complete": {
+            "post": {
+                "description": "If phone number is not registered yet, user is created with passed role on successful verification.\nRegistration policy of role is applied the same way as in register request.\nAccount deleted by user is restored if restore_account is set and grace period is not over, account deleted by admin can't be restored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Completing passwordless login: verifying sms code and giving tokens",
+                "parameters": [
+                    {
+                        "description": "Dto with phone number, SMS code and role (role is required only for new user)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoginCompleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Valid SMS code, giving access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoginCompleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid SMS code",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is deleted (error_code: account_deleted) suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/login/start": {
+            "post": {
+                "description": "Works for registered and new phone numbers alike, so it doesn't reveal whether phone number is registered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Starting passwordless login: sending sms code to phone number",
+                "parameters": [
+                    {
+                        "description": "Dto with phone number",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LoginStartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully sent code"
+                    },
+                    "400": {
+                        "description": "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Revoking access token from Authorization header (and session of refresh token if it's passed)",
+                "parameters": [
+                    {
+                        "description": "Dto with refresh token of session",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully logged out"
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/refresh": {
+            "post": {
+                "description": "Refresh token is single-use: it is rotated on every call. Reusing already rotated token revokes whole token family (all tokens issued since login)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Exchanging refresh token for new pair of access and refresh tokens",
+                "parameters": [
+                    {
+                        "description": "Dto with refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Refresh token is empty",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token reuse detected",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is deleted (error_code: account_deleted), suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/register": {
+            "post": {
+                "description": "Role with invite_code registration policy requires invite code. User with role with approval policy can't log in until admin approves them, unless valid invite code is passed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Create user entity in database, making them ready to log in",
+                "parameters": [
+                    {
+                        "description": "Register parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully created user in db"
+                    },
+                    "400": {
+                        "description": "Role requires invite code (error_code: invite_code_required) or invite code is invalid, expired or used up (invite_code_invalid)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this phone number already exists (error_code: user_already_exists)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/revoke": {
+            "post": {
+                "description": "Invalid or unknown tokens are not reported as error, as RFC 7009 requires. Revoking refresh token closes its whole session",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Revoking access or refresh token (RFC 7009)",
+                "parameters": [
+                    {
+                        "description": "Dto with token and optional hint of its type",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RevokeTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token is revoked"
+                    },
+                    "400": {
+                        "description": "Token is empty",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/send-sms-code": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Sending sms-code to user to phone number he entered",
+                "parameters": [
+                    {
+                        "description": "Dto with phone number",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SendSmsCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully sent code"
+                    },
+                    "400": {
+                        "description": "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/validate-token": {
+            "post": {
+                "description": "It checks if token is valid and not tried to be changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Checking if authentication token is valid",
+                "parameters": [
+                    {
+                        "description": "Dto containing token (format of JWT-token)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ValidateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dto with field 'is_valid' that shows if token is valid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ValidateTokenResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/verify-sms-code": {
+            "post": {
+                "description": "Code is checked before registration and status of user, so without valid code response doesn't reveal whether phone number is registered.\nCode is spent even if phone number is not registered, new user should use login/complete instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Verifying SMS code if it is what was sent to user",
+                "parameters": [
+                    {
+                        "description": "Dto with phone number and SMS code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.VerifySmsCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Valid SMS code, giving access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid SMS code",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is deleted (error_code: account_deleted) suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "Code is valid, but user is not registered (error_code: user_not_registered)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Profile of current user",
+                "responses": {
+                    "200": {
+                        "description": "Profile of user who owns token",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserProfileResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist anymore",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "User is marked deleted and all its sessions are revoked, deleted user can't log in. After grace period (users.deletion_grace_period_days) all data of user is purged.\nUntil then user can restore account by login with restore_account flag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deleting account of current user",
+                "responses": {
+                    "200": {
+                        "description": "Account is deleted, giving moment of purge",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeleteAccountResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist anymore",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Only passed fields are changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Changing profile of current user",
+                "parameters": [
+                    {
+                        "description": "Dto with changed fields of profile",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed profile",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserProfileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid display name",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist anymore",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Exporting all data AuthService holds on current user",
+                "responses": {
+                    "200": {
+                        "description": "Profile, additional roles and sessions of user",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserDataExportResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "404": {
+                        "description": "User doesn't exist anymore",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/phone/complete": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Request must be authenticated by token of account and confirmed by codes from current and new phone numbers.\nCode of current number is checked first and is spent even if code of new number is wrong, then change must be started again.\nAll other sessions of user are revoked, new pair of tokens is returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Completing change of phone number: verifying sms codes sent to current and new phone numbers",
+                "parameters": [
+                    {
+                        "description": "Dto with new phone number and SMS codes of current and new phone numbers",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CompletePhoneChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Phone number is changed, giving new access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid SMS code",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone number belongs to another user (error_code: phone_number_taken)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/phone/start": {
+            "post": {
+                "security": [
+                    {
+                        "JwtBearer": []
+                    }
+                ],
+                "description": "Both codes are required to complete change, so access token alone is not enough to move account to another number.\nLockouts and rate limits of both numbers are checked first, if any of them fails, no code is sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Starting change of phone number: sending sms codes to current and new phone numbers",
+                "parameters": [
+                    {
+                        "description": "Dto with new phone number",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.StartPhoneChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully sent codes to current and new phone numbers"
+                    },
+                    "400": {
+                        "description": "Invalid phone number (error_code: invalid_phone_number), country is not supported (phone_country_not_allowed) or number is the same (phone_number_unchanged)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "401": {
+                        "description": "Token is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone number belongs to another user (error_code: phone_number_taken)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "429": {
+                        "description": "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    },
+                    "500": {
+                        "description": "Happened internal error",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Returns 200 if the service is healthy and ready to accept traffic",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Infrastructure"
+                ],
+                "summary": "Health check endpoint for Kubernetes",
+                "responses": {
+                    "200": {
+                        "description": "Service is healthy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dtos.AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ChangeRoleRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CompletePhoneChangeRequest": {
+            "type": "object",
+            "properties": {
+                "current_sms_code": {
+                    "description": "Code sent to current phone number of user",
+                    "type": "string"
+                },
+                "new_phone_number": {
+                    "type": "string"
+                },
+                "sms_code": {
+                    "description": "Code sent to new phone number",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateInviteCodeRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in_hours": {
+                    "description": "168 (week) by default, 0 - code doesn't expire",
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "description": "1 by default",
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CreateInviteCodeResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Plain code, it's shown only once",
+                    "type": "string"
+                },
+                "invite_code": {
+                    "$ref": "#/definitions/dtos.InviteCodeDto"
+                }
+            }
+        },
+        "dtos.CreateRoleRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "is_self_assignable": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "registration_policy": {
+                    "description": "open (by default), invite_code or approval",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.DeleteAccountResponse": {
+            "type": "object",
+            "properties": {
+                "purge_at": {
+                    "description": "Until this moment data of user is kept, then it's purged",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ErrorDto": {
+            "type": "object",
+            "properties": {
+                "error_code": {
+                    "description": "Machine-readable reason of error, set for errors client has to handle specifically",
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.GenerateTokenRequest": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.IntrospectTokenRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "token_type_hint": {
+                    "description": "Only \"access_token\" is supported, other tokens are reported as inactive",
+                    "type": "string"
                 }
             }
         },
-        "dtos.GenerateTokenRequest": {
+        "dtos.IntrospectTokenResponse": {
             "type": "object",
             "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "aud": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "iss": {
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "description": "Same as scope, but as array",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "role": {
                     "type": "string"
                 },
-                "user_id": {
+                "roles": {
+                    "description": "All roles of user, primary role (role) is first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.InviteCodeDto": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "used_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.LoginCompleteRequest": {
+            "type": "object",
+            "properties": {
+                "invite_code": {
+                    "description": "Used only for new user, same as in register request",
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "restore_account": {
+                    "description": "Restores account which user deleted, if grace period is not over yet. Without it login of deleted account is rejected",
+                    "type": "boolean"
+                },
+                "role": {
+                    "description": "Required only for new user, ignored for registered one",
+                    "type": "string"
+                },
+                "sms_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.LoginCompleteResponse": {
+            "type": "object",
+            "properties": {
+                "is_new_user": {
+                    "description": "True if user was registered by this login",
+                    "type": "boolean"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.LoginStartRequest": {
+            "type": "object",
+            "properties": {
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Optional: if passed, session of this refresh token is closed too",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.RefreshTokenRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
                     "type": "string"
                 }
             }
@@ -271,6 +2484,10 @@ const docTemplate = `{
         "dtos.RegisterRequest": {
             "type": "object",
             "properties": {
+                "invite_code": {
+                    "description": "Required for roles with invite_code registration policy, lets skip approval for roles with approval policy",
+                    "type": "string"
+                },
                 "phone_number": {
                     "type": "string"
                 },
@@ -279,6 +2496,57 @@ const docTemplate = `{
                 }
             }
         },
+        "dtos.RevokeTokenRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "token_type_hint": {
+                    "description": "\"access_token\" or \"refresh_token\", if empty - both are tried",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.RoleDto": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_self_assignable": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "registration_policy": {
+                    "description": "open, invite_code or approval",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.RotateKeyResponse": {
+            "type": "object",
+            "properties": {
+                "kid": {
+                    "description": "Id of new signing key (\"kid\" header of new tokens)",
+                    "type": "string"
+                }
+            }
+        },
         "dtos.SendSmsCodeRequest": {
             "type": "object",
             "properties": {
@@ -287,6 +2555,56 @@ const docTemplate = `{
                 }
             }
         },
+        "dtos.SessionExportDto": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "family_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.SetUserRolesRequest": {
+            "type": "object",
+            "properties": {
+                "additional_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dtos.StartPhoneChangeRequest": {
+            "type": "object",
+            "properties": {
+                "new_phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.TokenPairResponse": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "dtos.TokenResponse": {
             "type": "object",
             "properties": {
@@ -295,6 +2613,123 @@ const docTemplate = `{
                 }
             }
         },
+        "dtos.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.UpdateRoleRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "is_self_assignable": {
+                    "type": "boolean"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "registration_policy": {
+                    "description": "open (by default), invite_code or approval",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.UserDataExportResponse": {
+            "type": "object",
+            "properties": {
+                "additional_roles": {
+                    "description": "Roles given to user besides primary one (primary role is in profile)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/dtos.UserProfileResponse"
+                },
+                "sessions": {
+                    "description": "Refresh tokens issued to user (tokens themselves are not stored, only their metadata)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.SessionExportDto"
+                    }
+                }
+            }
+        },
+        "dtos.UserProfileResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.UserRolesResponse": {
+            "type": "object",
+            "properties": {
+                "additional_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "primary_role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.UsersPageResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.AdminUserResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
         "dtos.ValidateTokenRequest": {
             "type": "object",
             "properties": {
@@ -321,9 +2756,57 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "services.JsonWebKey": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "EC and OKP (EdDSA)",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.JsonWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.JsonWebKey"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ClientBasic": {
+            "type": "basic"
+        },
         "JwtBearer": {
             "type": "apiKey",
             "name": "Authorization",
@@ -1,48 +1,38 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/phone"
 	"github.com/WebChads/AuthService/internal/services"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 type DatabaseContext struct {
 	Connection *sql.DB
 }
 
-func InitDatabase(databaseConfig *services.DatabaseConfig) (*DatabaseContext, error) {
-	connectionString := fmt.Sprintf("postgres://%s:%s@%s/postgres?sslmode=disable", databaseConfig.User, databaseConfig.Password, databaseConfig.Host)
-
-	connection, err := sql.Open("postgres", connectionString)
-	if err != nil {
-		return nil, fmt.Errorf("conn 1: %w", err)
-	}
-
-	doesDbExists, err := checkIfDbExists(connection, databaseConfig.DbName)
+func InitDatabase(databaseConfig *services.DatabaseConfig, phoneParser phone.Parser, logger *zap.Logger) (*DatabaseContext, error) {
+	databaseContextObject, err := openDatabase(databaseConfig, logger)
 	if err != nil {
-		return nil, fmt.Errorf("conn doesDbExists: %w", err)
-	}
-
-	if !doesDbExists {
-		err = createDatabase(connection, databaseConfig.DbName)
-		if err != nil {
-			return nil, fmt.Errorf("conn createDatabase: %w", err)
-		}
+		return nil, err
 	}
 
-	connectionString = fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", databaseConfig.User, databaseConfig.Password, databaseConfig.Host, databaseConfig.DbName)
-	connection, err = sql.Open("postgres", connectionString)
+	migrator, err := NewMigrator(databaseContextObject.Connection, phoneParser, logger)
 	if err != nil {
-		return nil, fmt.Errorf("conn 2: %w", err)
+		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
-	databaseContextObject := &DatabaseContext{Connection: connection}
-
-	err = databaseContextObject.migrateTables()
+	err = migrator.Up()
 	if err != nil {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
@@ -50,115 +40,160 @@ func InitDatabase(databaseConfig *services.DatabaseConfig) (*DatabaseContext, er
 	return databaseContextObject, nil
 }
 
-func checkIfDbExists(connection *sql.DB, dbName string) (bool, error) {
-	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)"
+// Rolls back given amount of last applied migrations, service isn't started then
+func RollbackMigrations(databaseConfig *services.DatabaseConfig, phoneParser phone.Parser, logger *zap.Logger, steps int) error {
+	databaseContextObject, err := openDatabase(databaseConfig, logger)
+	if err != nil {
+		return err
+	}
+	defer databaseContextObject.Connection.Close()
 
-	err := connection.QueryRow(query, dbName).Scan(&exists)
+	migrator, err := NewMigrator(databaseContextObject.Connection, phoneParser, logger)
 	if err != nil {
-		return false, fmt.Errorf("query failed: %w", err)
+		return fmt.Errorf("migrate: %w", err)
 	}
 
-	return exists, nil
+	return migrator.Down(steps)
 }
 
-func createDatabase(connection *sql.DB, dbName string) error {
-	createDbCommand := fmt.Sprintf("CREATE DATABASE %s", pq.QuoteIdentifier(dbName))
-	_, err := connection.Exec(createDbCommand)
+// Creates database if it doesn't exist yet and connects to it
+func openDatabase(databaseConfig *services.DatabaseConfig, logger *zap.Logger) (*DatabaseContext, error) {
+	connectionString := databaseConfig.Dsn
+
+	if connectionString == "" {
+		if !databaseConfig.SkipCreateDatabase {
+			err := ensureDatabaseExists(databaseConfig, logger)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		connectionString = buildConnectionString(databaseConfig, databaseConfig.DbName)
+	}
+
+	connection, err := connect(connectionString, databaseConfig, logger)
 	if err != nil {
-		return fmt.Errorf("failed to create database: %w", err)
+		return nil, fmt.Errorf("conn: %w", err)
 	}
-	return nil
+
+	connection.SetMaxOpenConns(databaseConfig.MaxOpenConns)
+	connection.SetMaxIdleConns(databaseConfig.MaxIdleConns)
+	connection.SetConnMaxLifetime(databaseConfig.ConnMaxLifetime())
+
+	return &DatabaseContext{Connection: connection}, nil
 }
 
-func (databaseContext *DatabaseContext) migrateTables() error {
-	if databaseContext.Connection == nil {
-		return errors.New("there are no connection while migration of tables")
+// Connects to maintenance "postgres" database to create configured one
+func ensureDatabaseExists(databaseConfig *services.DatabaseConfig, logger *zap.Logger) error {
+	connection, err := connect(buildConnectionString(databaseConfig, "postgres"), databaseConfig, logger)
+	if err != nil {
+		return fmt.Errorf("conn maintenance database: %w", err)
 	}
+	defer connection.Close()
 
-	isUsersExists, err := databaseContext.checkIfTableExists("users")
+	doesDbExists, err := checkIfDbExists(connection, databaseConfig.DbName)
 	if err != nil {
-		return err
+		return fmt.Errorf("conn doesDbExists: %w", err)
 	}
 
-	if !isUsersExists {
-		err = databaseContext.createTableUsers()
+	if !doesDbExists {
+		err = createDatabase(connection, databaseConfig.DbName)
 		if err != nil {
-			return err
+			return fmt.Errorf("conn createDatabase: %w", err)
 		}
 	}
 
-	err = databaseContext.createIndexOnTableUsers()
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func (databaseContext *DatabaseContext) checkIfTableExists(tableName string) (bool, error) {
-	sqlQuery := `SELECT EXISTS (
-        SELECT 1 FROM information_schema.tables 
-        WHERE table_name = $1
-    )`
-
-	var exists bool
-	err := databaseContext.Connection.QueryRow(sqlQuery, tableName).Scan(&exists)
-	if err != nil {
-		return false, fmt.Errorf("error checking if table exists: %w", err)
+func buildConnectionString(databaseConfig *services.DatabaseConfig, dbName string) string {
+	host := databaseConfig.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, strconv.Itoa(databaseConfig.Port))
 	}
 
-	return exists, nil
-}
+	parameters := url.Values{}
+	parameters.Set("sslmode", databaseConfig.SslMode)
+	if databaseConfig.SslRootCert != "" {
+		parameters.Set("sslrootcert", databaseConfig.SslRootCert)
+	}
 
-func (databaseContext *DatabaseContext) createTableUsers() error {
-	usersTable := `CREATE TABLE users
-    (
-        id uuid PRIMARY KEY NOT NULL,
-        phone_number varchar(12) NOT NULL,
-        user_role varchar(25) NOT NULL
-    )
-`
-	_, err := databaseContext.Connection.Exec(usersTable)
-	if err != nil {
-		return err
+	connectionUrl := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(databaseConfig.User, databaseConfig.Password),
+		Host:     host,
+		Path:     "/" + dbName,
+		RawQuery: parameters.Encode(),
 	}
 
-	return nil
+	return connectionUrl.String()
 }
 
-func (databaseContext *DatabaseContext) createIndexOnTableUsers() error {
-	exists, err := databaseContext.checkIfIndexExists("index_users_phone_number")
+// Database may start later than service (e.g. in docker compose), so it's pinged with growing delay
+func connect(connectionString string, databaseConfig *services.DatabaseConfig, logger *zap.Logger) (*sql.DB, error) {
+	connection, err := sql.Open("postgres", connectionString)
 	if err != nil {
-		return fmt.Errorf("failed to check index existence: %w", err)
+		return nil, err
 	}
 
-	if exists {
-		return nil
-	}
+	delay := databaseConfig.ConnectRetryDelay()
+	for attempt := 1; ; attempt++ {
+		err = connection.Ping()
+		if err == nil {
+			return connection, nil
+		}
 
-	usersIndex := "CREATE INDEX index_users_phone_number ON users (phone_number)"
-	_, err = databaseContext.Connection.Exec(usersIndex)
-	if err != nil {
-		return err
+		if attempt >= databaseConfig.ConnectAttempts {
+			connection.Close()
+			return nil, fmt.Errorf("database is unavailable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("Database is unavailable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		time.Sleep(delay)
+		delay = min(delay*2, databaseConfig.ConnectMaxRetryDelay())
 	}
+}
 
-	return nil
+// Repositories working with this database
+func (databaseContext *DatabaseContext) Storage(phoneParser phone.Parser) *repositories.Storage {
+	return &repositories.Storage{
+		Users:         repositories.NewUserRepository(databaseContext.Connection, phoneParser),
+		Roles:         repositories.NewRoleRepository(databaseContext.Connection),
+		RefreshTokens: repositories.NewRefreshTokenRepository(databaseContext.Connection),
+		RevokedTokens: repositories.NewRevokedTokenRepository(databaseContext.Connection),
+		SigningKeys:   repositories.NewSigningKeyRepository(databaseContext.Connection),
+		InviteCodes:   repositories.NewInviteCodeRepository(databaseContext.Connection),
+		Outbox:        repositories.NewOutboxRepository(databaseContext.Connection),
+		Transactions:  databaseContext,
+	}
 }
 
-func (databaseContext *DatabaseContext) checkIfIndexExists(indexName string) (bool, error) {
-	query := `
-        SELECT EXISTS (
-            SELECT 1 FROM pg_indexes 
-            WHERE indexname = $1 
-            AND tablename = 'users'
-        )`
+// Implements repositories.TransactionRunner
+func (databaseContext *DatabaseContext) InTransaction(ctx context.Context, action func(ctx context.Context) error) error {
+	return repositories.RunInTransaction(ctx, databaseContext.Connection, action)
+}
 
+func checkIfDbExists(connection *sql.DB, dbName string) (bool, error) {
 	var exists bool
-	err := databaseContext.Connection.QueryRow(query, indexName).Scan(&exists)
+	query := "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)"
+
+	err := connection.QueryRow(query, dbName).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check index existence: %w", err)
+		return false, fmt.Errorf("query failed: %w", err)
 	}
 
 	return exists, nil
 }
+
+func createDatabase(connection *sql.DB, dbName string) error {
+	createDbCommand := fmt.Sprintf("CREATE DATABASE %s", pq.QuoteIdentifier(dbName))
+	_, err := connection.Exec(createDbCommand)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	return nil
+}
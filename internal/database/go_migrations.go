@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/WebChads/AuthService/internal/phone"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Migrations which can't be written in SQL. Their versions are shared with SQL migrations in migrations directory
+func goMigrations(phoneParser phone.Parser, logger *zap.Logger) []migration {
+	return []migration{
+		{
+			Version: 9,
+			Name:    "normalize_users_phone_numbers",
+			Up: func(transaction *sql.Tx) error {
+				return normalizeUserPhoneNumbers(transaction, phoneParser, logger)
+			},
+		},
+	}
+}
+
+// Users registered before normalization could have numbers like "8 (912) 345-67-89", they are converted to E.164.
+// Number which can't be parsed is left as is and logged: such user can't log in until number is fixed manually.
+// Number which becomes equal to number of another user is converted too and logged, then migration creating
+// unique index fails on such duplicates, so they are resolved manually instead of one of users being lost
+func normalizeUserPhoneNumbers(transaction *sql.Tx, phoneParser phone.Parser, logger *zap.Logger) error {
+	rows, err := transaction.Query(`SELECT id, phone_number FROM users WHERE phone_number !~ '^\+[0-9]+$'`)
+	if err != nil {
+		return fmt.Errorf("while retrieving not normalized phone numbers happened error: %w", err)
+	}
+	defer rows.Close()
+
+	phoneNumbersById := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var phoneNumber string
+
+		err = rows.Scan(&id, &phoneNumber)
+		if err != nil {
+			return fmt.Errorf("while retrieving not normalized phone numbers happened error: %w", err)
+		}
+
+		phoneNumbersById[id] = phoneNumber
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("while retrieving not normalized phone numbers happened error: %w", err)
+	}
+
+	amountOfNormalized := 0
+	for id, phoneNumber := range phoneNumbersById {
+		normalizedPhoneNumber, err := phoneParser.Normalize(phoneNumber)
+		if err != nil {
+			logger.Warn("phone number of user can't be normalized and is left as is, user can't log in until it's fixed",
+				zap.String("user_id", id.String()),
+				zap.String("phone_number", phoneNumber),
+				zap.Error(err))
+			continue
+		}
+
+		var otherUserId uuid.UUID
+		err = transaction.QueryRow("SELECT id FROM users WHERE phone_number = $1 AND id <> $2 LIMIT 1", normalizedPhoneNumber, id).Scan(&otherUserId)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("while searching users with phone number %s happened error: %w", normalizedPhoneNumber, err)
+		}
+
+		if err == nil {
+			logger.Warn("normalized phone number of user belongs to another user, duplicate must be removed manually",
+				zap.String("user_id", id.String()),
+				zap.String("other_user_id", otherUserId.String()),
+				zap.String("phone_number", normalizedPhoneNumber))
+		}
+
+		_, err = transaction.Exec("UPDATE users SET phone_number = $2 WHERE id = $1", id, normalizedPhoneNumber)
+		if err != nil {
+			return fmt.Errorf("while normalizing phone number of user %s happened error: %w", id, err)
+		}
+
+		amountOfNormalized++
+	}
+
+	logger.Info(fmt.Sprintf("phone numbers of %d users are normalized, %d are left as is", amountOfNormalized, len(phoneNumbersById)-amountOfNormalized))
+	return nil
+}
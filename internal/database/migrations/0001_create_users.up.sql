@@ -0,0 +1,27 @@
+-- Statements are idempotent, so database created before migrations is brought to the same schema
+CREATE TABLE IF NOT EXISTS users
+(
+    id uuid PRIMARY KEY NOT NULL,
+    phone_number varchar(16) NOT NULL,
+    user_role varchar(25) NOT NULL,
+    display_name varchar(64) NOT NULL DEFAULT '',
+    status varchar(20) NOT NULL DEFAULT 'active',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    last_login_at timestamptz NULL,
+    deleted_at timestamptz NULL
+);
+
+-- Table could be created with length of russian number, E.164 number can be up to 16 symbols
+ALTER TABLE users ALTER COLUMN phone_number TYPE varchar(16);
+
+-- Table could be created before users had profile
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name varchar(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_login_at timestamptz NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS index_users_phone_number ON users (phone_number);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id uuid PRIMARY KEY NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id uuid NOT NULL,
+    token_hash varchar(64) NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz NULL,
+    replaced_by uuid NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_refresh_tokens_family_id ON refresh_tokens (family_id);
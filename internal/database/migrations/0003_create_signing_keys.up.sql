@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    id varchar(64) PRIMARY KEY NOT NULL,
+    algorithm varchar(10) NOT NULL,
+    private_key bytea NOT NULL,
+    created_at timestamptz NOT NULL,
+    retired_at timestamptz NULL,
+    expires_at timestamptz NULL
+);
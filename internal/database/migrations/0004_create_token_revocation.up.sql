@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    token_id varchar(64) PRIMARY KEY NOT NULL,
+    revoked_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS user_token_cutoffs
+(
+    user_id uuid PRIMARY KEY NOT NULL,
+    not_before timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
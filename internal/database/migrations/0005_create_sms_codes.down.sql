@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sms_lockouts;
+DROP TABLE IF EXISTS sms_failed_attempts;
+DROP TABLE IF EXISTS sms_codes;
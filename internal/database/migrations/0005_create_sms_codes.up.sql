@@ -0,0 +1,22 @@
+-- Column code keeps HMAC of sms code, not code itself
+CREATE TABLE IF NOT EXISTS sms_codes
+(
+    phone_number varchar(20) PRIMARY KEY NOT NULL,
+    code varchar(64) NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+-- Failed attempts are counted per phone number, not per code, so sending new code doesn't reset them.
+-- Counter is reset after successful verification or when expires_at passes: lockout_minutes after last failure or end of lockout
+CREATE TABLE IF NOT EXISTS sms_failed_attempts
+(
+    phone_number varchar(20) PRIMARY KEY NOT NULL,
+    failed_attempts int NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sms_lockouts
+(
+    phone_number varchar(20) PRIMARY KEY NOT NULL,
+    locked_until timestamptz NOT NULL
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS rate_limit_events
+(
+    key varchar(128) NOT NULL,
+    occurred_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_rate_limit_events_key_occurred_at ON rate_limit_events (key, occurred_at);
+CREATE INDEX IF NOT EXISTS index_rate_limit_events_expires_at ON rate_limit_events (expires_at);
@@ -0,0 +1,46 @@
+-- Roles of user are its primary role (users.user_role) and additional roles from user_roles.
+-- Permissions are free-form strings (e.g. "trainings:write") interpreted by services which check tokens
+CREATE TABLE IF NOT EXISTS roles
+(
+    name varchar(25) PRIMARY KEY NOT NULL,
+    description varchar(256) NOT NULL DEFAULT '',
+    is_self_assignable boolean NOT NULL DEFAULT false,
+    registration_policy varchar(20) NOT NULL DEFAULT 'open',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_name varchar(25) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission varchar(100) NOT NULL,
+    PRIMARY KEY (role_name, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles
+(
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_name varchar(25) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    granted_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role_name)
+);
+
+-- Table could be created before registration policies appeared. Trainers got their privileges without any check,
+-- so role gets approval policy once, further changes are made by admins
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'roles' AND column_name = 'registration_policy') THEN
+        ALTER TABLE roles ADD COLUMN registration_policy varchar(20) NOT NULL DEFAULT 'open';
+        UPDATE roles SET registration_policy = 'approval' WHERE name = 'Trainer';
+    END IF;
+END $$;
+
+-- Roles which were hardcoded before roles became data, and roles of existing users,
+-- so every primary role of user has definition
+INSERT INTO roles (name, is_self_assignable, registration_policy)
+    VALUES ('Player', true, 'open'), ('Trainer', true, 'approval'), ('Admin', false, 'open')
+    ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name)
+    SELECT DISTINCT user_role FROM users
+    ON CONFLICT (name) DO NOTHING;
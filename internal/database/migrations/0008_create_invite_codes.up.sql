@@ -0,0 +1,13 @@
+-- Column code_hash keeps SHA-256 of invite code, not code itself
+CREATE TABLE IF NOT EXISTS invite_codes
+(
+    id uuid PRIMARY KEY NOT NULL,
+    code_hash varchar(64) NOT NULL UNIQUE,
+    role_name varchar(25) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    max_uses int NOT NULL,
+    used_count int NOT NULL DEFAULT 0,
+    created_by uuid NOT NULL,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NULL,
+    revoked_at timestamptz NULL
+);
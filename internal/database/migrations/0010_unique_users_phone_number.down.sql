@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_users_phone_number;
+CREATE INDEX index_users_phone_number ON users (phone_number);
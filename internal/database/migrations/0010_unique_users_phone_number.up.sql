@@ -0,0 +1,18 @@
+-- Concurrent registrations could create several users with the same phone number. Migration doesn't choose which of them
+-- to keep: it fails, so duplicates are removed manually before unique index is created. Duplicates can be found by
+-- SELECT phone_number, array_agg(id ORDER BY created_at) FROM users GROUP BY phone_number HAVING count(*) > 1;
+DO $$
+DECLARE
+    duplicated_phone_numbers bigint;
+BEGIN
+    SELECT count(*) INTO duplicated_phone_numbers
+    FROM (SELECT phone_number FROM users GROUP BY phone_number HAVING count(*) > 1) AS duplicates;
+
+    IF duplicated_phone_numbers > 0 THEN
+        RAISE EXCEPTION '% phone numbers belong to several users, remove duplicated users before unique index is created',
+            duplicated_phone_numbers;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS index_users_phone_number;
+CREATE UNIQUE INDEX index_users_phone_number ON users (phone_number);
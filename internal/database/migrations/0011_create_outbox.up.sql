@@ -0,0 +1,19 @@
+-- Messages to kafka written in the same transaction as changes they are about, see OutboxRelay.
+-- Message which failed outbox.max_attempts times gets failed_at and is moved out of queue, so it doesn't block
+-- next messages of its key. It's kept for investigation and can be sent again by setting failed_at to NULL
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id bigserial PRIMARY KEY NOT NULL,
+    topic varchar(255) NOT NULL,
+    message_key varchar(255) NOT NULL,
+    payload bytea NOT NULL,
+    created_at timestamptz NOT NULL,
+    attempts int NOT NULL DEFAULT 0,
+    last_error text NULL,
+    next_attempt_at timestamptz NOT NULL,
+    delivered_at timestamptz NULL,
+    failed_at timestamptz NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_outbox_pending ON outbox (topic, message_key, id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS index_outbox_delivered_at ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
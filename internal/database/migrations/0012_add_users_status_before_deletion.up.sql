@@ -0,0 +1,3 @@
+-- Status of user who deleted own account, account is returned to it if user restores account during grace period.
+-- NULL for accounts deleted by admin: user can't undo such deletion
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_before_deletion varchar(20) NULL;
@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/WebChads/AuthService/internal/phone"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Name of file is "<version>_<name>.<up|down>.sql", e.g. "0001_create_users.up.sql"
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Key of advisory lock, so replicas which start together apply migrations one after another
+const migrationsLockKey = 7253120498
+
+var ErrMigrationChanged = errors.New("applied migration was changed")
+
+type migration struct {
+	Version int
+	Name    string
+	UpSql   string
+	DownSql string
+
+	// Set instead of UpSql for migrations written in Go (see goMigrations), DownSql of such migration may be empty
+	Up func(transaction *sql.Tx) error
+
+	// SHA-256 of up script, it's saved with applied migration
+	Checksum string
+}
+
+// Applies embedded SQL migrations and migrations written in Go, records them in schema_migrations table.
+// Every migration is applied in its own transaction together with its record
+type Migrator struct {
+	connection *sql.DB
+	logger     *zap.Logger
+	migrations []migration
+}
+
+func NewMigrator(connection *sql.DB, phoneParser phone.Parser, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := embeddedMigrations(phoneParser, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{connection: connection, logger: logger, migrations: migrations}, nil
+}
+
+// Embedded SQL migrations together with migrations written in Go, sorted by version
+func embeddedMigrations(phoneParser phone.Parser, logger *zap.Logger) ([]migration, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, goMigration := range goMigrations(phoneParser, logger) {
+		index := slices.IndexFunc(migrations, func(migration migration) bool { return migration.Version == goMigration.Version })
+		if index != -1 {
+			return nil, fmt.Errorf("migrations %d_%s and %d_%s have the same version", goMigration.Version, migrations[index].Name, goMigration.Version, goMigration.Name)
+		}
+
+		// Code of migration can't be hashed, so only its name is checked
+		checksum := sha256.Sum256([]byte(goMigration.Name))
+		goMigration.Checksum = hex.EncodeToString(checksum[:])
+
+		migrations = append(migrations, goMigration)
+	}
+
+	slices.SortFunc(migrations, func(first migration, second migration) int { return first.Version - second.Version })
+
+	return migrations, nil
+}
+
+// Applies all migrations which are not applied yet
+func (migrator *Migrator) Up() error {
+	return migrator.withLock(func(connection *sql.Conn) error {
+		appliedChecksums, err := migrator.getAppliedMigrations(connection)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			if _, isApplied := appliedChecksums[migration.Version]; isApplied {
+				continue
+			}
+
+			err = migrator.apply(connection, migration)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Rolls back given amount of last applied migrations
+func (migrator *Migrator) Down(steps int) error {
+	return migrator.withLock(func(connection *sql.Conn) error {
+		appliedChecksums, err := migrator.getAppliedMigrations(connection)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrator.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := migrator.migrations[i]
+			if _, isApplied := appliedChecksums[migration.Version]; !isApplied {
+				continue
+			}
+
+			err = migrator.rollBack(connection, migration)
+			if err != nil {
+				return err
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Runs action on single connection holding advisory lock. Lock is released when connection is closed,
+// so it can't stay taken if process dies
+func (migrator *Migrator) withLock(action func(connection *sql.Conn) error) error {
+	ctx := context.Background()
+
+	connection, err := migrator.connection.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("while getting connection for migrations happened error: %w", err)
+	}
+	defer connection.Close()
+
+	_, err = connection.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey)
+	if err != nil {
+		return fmt.Errorf("while taking lock for migrations happened error: %w", err)
+	}
+
+	defer func() {
+		_, err := connection.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+		if err != nil {
+			migrator.logger.Error(fmt.Errorf("while releasing lock for migrations happened error: %w", err).Error())
+		}
+	}()
+
+	_, err = connection.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+    (
+        version bigint PRIMARY KEY NOT NULL,
+        name varchar(100) NOT NULL,
+        checksum varchar(64) NOT NULL,
+        applied_at timestamptz NOT NULL DEFAULT now()
+    )`)
+	if err != nil {
+		return fmt.Errorf("while creating table of migrations happened error: %w", err)
+	}
+
+	return action(connection)
+}
+
+// Returns checksums of applied migrations by their versions. Fails if any applied migration was changed since then
+func (migrator *Migrator) getAppliedMigrations(connection *sql.Conn) (map[int]string, error) {
+	rows, err := connection.QueryContext(context.Background(), "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving applied migrations happened error: %w", err)
+	}
+	defer rows.Close()
+
+	appliedChecksums := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var checksum string
+
+		err = rows.Scan(&version, &checksum)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving applied migrations happened error: %w", err)
+		}
+
+		appliedChecksums[version] = checksum
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving applied migrations happened error: %w", err)
+	}
+
+	err = migrator.checkAppliedMigrations(appliedChecksums)
+	if err != nil {
+		return nil, err
+	}
+
+	return appliedChecksums, nil
+}
+
+// Returns ErrMigrationChanged if checksum of any applied migration differs from checksum of its up script
+func (migrator *Migrator) checkAppliedMigrations(appliedChecksums map[int]string) error {
+	for version, checksum := range appliedChecksums {
+		index := slices.IndexFunc(migrator.migrations, func(migration migration) bool { return migration.Version == version })
+
+		// Database could be migrated by newer version of service, e.g. during rolling update
+		if index == -1 {
+			migrator.logger.Warn(fmt.Sprintf("migration %d is applied, but unknown to this version of service", version))
+			continue
+		}
+
+		if migrator.migrations[index].Checksum != checksum {
+			return fmt.Errorf("%w: %d_%s", ErrMigrationChanged, version, migrator.migrations[index].Name)
+		}
+	}
+
+	return nil
+}
+
+func (migrator *Migrator) apply(connection *sql.Conn, migration migration) error {
+	err := inTransaction(connection, func(transaction *sql.Tx) error {
+		var err error
+		if migration.Up != nil {
+			err = migration.Up(transaction)
+		} else {
+			_, err = transaction.Exec(migration.UpSql)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = transaction.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("while applying migration %d_%s happened error: %w", migration.Version, migration.Name, err)
+	}
+
+	migrator.logger.Info(fmt.Sprintf("migration %d_%s is applied", migration.Version, migration.Name))
+	return nil
+}
+
+func (migrator *Migrator) rollBack(connection *sql.Conn, migration migration) error {
+	err := inTransaction(connection, func(transaction *sql.Tx) error {
+		// Migration written in Go can have nothing to roll back, then only its record is deleted
+		if migration.DownSql != "" {
+			_, err := transaction.Exec(migration.DownSql)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := transaction.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("while rolling back migration %d_%s happened error: %w", migration.Version, migration.Name, err)
+	}
+
+	migrator.logger.Info(fmt.Sprintf("migration %d_%s is rolled back", migration.Version, migration.Name))
+	return nil
+}
+
+func inTransaction(connection *sql.Conn, action func(transaction *sql.Tx) error) error {
+	transaction, err := connection.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	err = action(transaction)
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+// Reads migrations sorted by version. Every migration must have both up and down scripts
+func loadMigrations(files fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("while reading migrations happened error: %w", err)
+	}
+
+	migrationsByVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid name of migration file: %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of migration file: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(files, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("while reading migration %s happened error: %w", entry.Name(), err)
+		}
+
+		current, exists := migrationsByVersion[version]
+		if !exists {
+			current = &migration{Version: version, Name: match[2]}
+			migrationsByVersion[version] = current
+		}
+
+		if current.Name != match[2] {
+			return nil, fmt.Errorf("migrations %d_%s and %d_%s have the same version", version, current.Name, version, match[2])
+		}
+
+		if match[3] == "up" {
+			current.UpSql = string(content)
+			checksum := sha256.Sum256(content)
+			current.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			current.DownSql = string(content)
+		}
+	}
+
+	migrations := []migration{}
+	for _, current := range migrationsByVersion {
+		if current.UpSql == "" || current.DownSql == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down scripts", current.Version, current.Name)
+		}
+
+		migrations = append(migrations, *current)
+	}
+
+	slices.SortFunc(migrations, func(first migration, second migration) int { return first.Version - second.Version })
+
+	return migrations, nil
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/WebChads/AuthService/internal/phone"
+	"go.uber.org/zap"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0002_add_status.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN status text;")},
+		"migrations/0002_add_status.down.sql":    {Data: []byte("ALTER TABLE users DROP COLUMN status;")},
+		"migrations/0001_create_users.up.sql":    {Data: []byte("CREATE TABLE users (id uuid);")},
+		"migrations/0001_create_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"migrations/0010_create_outbox.up.sql":   {Data: []byte("CREATE TABLE outbox (id bigserial);")},
+		"migrations/0010_create_outbox.down.sql": {Data: []byte("DROP TABLE outbox;")},
+	}
+
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedNames := []string{"create_users", "add_status", "create_outbox"}
+	expectedVersions := []int{1, 2, 10}
+	if len(migrations) != len(expectedNames) {
+		t.Fatalf("expected %d migrations, got %d", len(expectedNames), len(migrations))
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != expectedVersions[i] || migration.Name != expectedNames[i] {
+			t.Fatalf("expected migration %d_%s at position %d, got %d_%s", expectedVersions[i], expectedNames[i], i, migration.Version, migration.Name)
+		}
+	}
+
+	if migrations[0].UpSql != "CREATE TABLE users (id uuid);" || migrations[0].DownSql != "DROP TABLE users;" {
+		t.Fatalf("scripts are mixed up: %+v", migrations[0])
+	}
+
+	checksum := sha256.Sum256([]byte("CREATE TABLE users (id uuid);"))
+	if migrations[0].Checksum != hex.EncodeToString(checksum[:]) {
+		t.Fatalf("checksum must be SHA-256 of up script, got %s", migrations[0].Checksum)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         fstest.MapFS
+		expectedError string
+	}{
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"migrations/0001_CreateUsers.up.sql": {Data: []byte("CREATE TABLE users (id uuid);")},
+			},
+			expectedError: "invalid name of migration file",
+		},
+		{
+			name: "file without direction",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.sql": {Data: []byte("CREATE TABLE users (id uuid);")},
+			},
+			expectedError: "invalid name of migration file",
+		},
+		{
+			name: "missing down script",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id uuid);")},
+			},
+			expectedError: "must have both up and down scripts",
+		},
+		{
+			name: "missing up script",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			},
+			expectedError: "must have both up and down scripts",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id uuid);")},
+				"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+				"migrations/0001_create_roles.up.sql":   {Data: []byte("CREATE TABLE roles (name text);")},
+				"migrations/0001_create_roles.down.sql": {Data: []byte("DROP TABLE roles;")},
+			},
+			expectedError: "have the same version",
+		},
+		{
+			name:          "missing directory",
+			files:         fstest.MapFS{},
+			expectedError: "while reading migrations happened error",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := loadMigrations(testCase.files)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsAreValid(t *testing.T) {
+	phoneParser, err := phone.NewParser([]string{"7"}, "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := embeddedMigrations(phoneParser, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Fatalf("expected migration with version %d, got %d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestCheckAppliedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(fstest.MapFS{
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id uuid);")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/0002_add_status.up.sql":     {Data: []byte("ALTER TABLE users ADD COLUMN status text;")},
+		"migrations/0002_add_status.down.sql":   {Data: []byte("ALTER TABLE users DROP COLUMN status;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator := &Migrator{logger: zap.NewNop(), migrations: migrations}
+
+	t.Run("applied migrations are not changed", func(t *testing.T) {
+		err := migrator.checkAppliedMigrations(map[int]string{1: migrations[0].Checksum})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("migration applied by newer version of service is skipped", func(t *testing.T) {
+		err := migrator.checkAppliedMigrations(map[int]string{1: migrations[0].Checksum, 3: "unknown"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("changed migration is rejected", func(t *testing.T) {
+		err := migrator.checkAppliedMigrations(map[int]string{1: migrations[0].Checksum, 2: migrations[0].Checksum})
+		if !errors.Is(err, ErrMigrationChanged) {
+			t.Fatalf("expected ErrMigrationChanged, got %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "2_add_status") {
+			t.Fatalf("error must name changed migration, got %v", err)
+		}
+	})
+}
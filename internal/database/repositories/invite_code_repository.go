@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+type InviteCodeRepository interface {
+	Add(ctx context.Context, inviteCode *entities.InviteCode) error
+
+	// Returns all codes, newest first
+	GetAll(ctx context.Context) ([]*entities.InviteCode, error)
+
+	// Returns false if code does not exists or already revoked
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (bool, error)
+
+	// Returns code for role if it can be used right now, otherwise nil, nil
+	GetUsable(ctx context.Context, codeHash string, roleName string) (*entities.InviteCode, error)
+
+	// Uses code for role once. Returns false if code can't be used (e.g. its uses ran out in the meantime)
+	Use(ctx context.Context, codeHash string, roleName string) (bool, error)
+}
+
+const inviteCodeColumns = "id, code_hash, role_name, max_uses, used_count, created_by, created_at, expires_at, revoked_at"
+
+// Condition of code which is not revoked, not expired and has uses left, $1 - code hash, $2 - role, $3 - now
+const usableInviteCodeCondition = `code_hash = $1 AND role_name = $2 AND revoked_at IS NULL
+	AND (expires_at IS NULL OR expires_at > $3) AND used_count < max_uses`
+
+// Implementation of InviteCodeRepository for database/sql + PostgreSQL
+type PgInviteCodeRepository struct {
+	connection *sql.DB
+}
+
+func NewInviteCodeRepository(connection *sql.DB) InviteCodeRepository {
+	return &PgInviteCodeRepository{connection: connection}
+}
+
+func (repository *PgInviteCodeRepository) Add(ctx context.Context, inviteCode *entities.InviteCode) error {
+	addQuery := `INSERT INTO invite_codes (id, code_hash, role_name, max_uses, used_count, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, addQuery, inviteCode.Id, inviteCode.CodeHash, inviteCode.RoleName, inviteCode.MaxUses,
+		inviteCode.UsedCount, inviteCode.CreatedBy, inviteCode.CreatedAt, inviteCode.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("while adding invite code happened error: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *PgInviteCodeRepository) GetAll(ctx context.Context) ([]*entities.InviteCode, error) {
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, "SELECT "+inviteCodeColumns+" FROM invite_codes ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving invite codes happened error: %w", err)
+	}
+	defer rows.Close()
+
+	inviteCodes := []*entities.InviteCode{}
+	for rows.Next() {
+		inviteCode, err := scanInviteCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving invite codes happened error: %w", err)
+		}
+
+		inviteCodes = append(inviteCodes, inviteCode)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving invite codes happened error: %w", err)
+	}
+
+	return inviteCodes, nil
+}
+
+func (repository *PgInviteCodeRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "UPDATE invite_codes SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL", id, revokedAt)
+	if err != nil {
+		return false, fmt.Errorf("while revoking invite code %s happened error: %w", id, err)
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("while revoking invite code %s happened error: %w", id, err)
+	}
+
+	return affectedRows == 1, nil
+}
+
+func (repository *PgInviteCodeRepository) GetUsable(ctx context.Context, codeHash string, roleName string) (*entities.InviteCode, error) {
+	row := getExecutor(ctx, repository.connection).QueryRowContext(ctx, "SELECT "+inviteCodeColumns+" FROM invite_codes WHERE "+usableInviteCodeCondition, codeHash, roleName, time.Now())
+
+	inviteCode, err := scanInviteCode(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving invite code happened error: %w", err)
+	}
+
+	return inviteCode, nil
+}
+
+func (repository *PgInviteCodeRepository) Use(ctx context.Context, codeHash string, roleName string) (bool, error) {
+	// Limit of uses is checked by the same statement which counts use
+	useQuery := "UPDATE invite_codes SET used_count = used_count + 1 WHERE " + usableInviteCodeCondition
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, useQuery, codeHash, roleName, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("while using invite code happened error: %w", err)
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("while using invite code happened error: %w", err)
+	}
+
+	return affectedRows == 1, nil
+}
+
+func scanInviteCode(row rowScanner) (*entities.InviteCode, error) {
+	inviteCode := &entities.InviteCode{}
+	var expiresAt, revokedAt sql.NullTime
+
+	err := row.Scan(&inviteCode.Id, &inviteCode.CodeHash, &inviteCode.RoleName, &inviteCode.MaxUses, &inviteCode.UsedCount,
+		&inviteCode.CreatedBy, &inviteCode.CreatedAt, &expiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		inviteCode.ExpiresAt = &expiresAt.Time
+	}
+
+	if revokedAt.Valid {
+		inviteCode.RevokedAt = &revokedAt.Time
+	}
+
+	return inviteCode, nil
+}
+
+// Implementation of InviteCodeRepository which keeps codes in memory
+type InMemoryInviteCodeRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemoryInviteCodeRepository) Add(ctx context.Context, inviteCode *entities.InviteCode) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	// The same checks as foreign key and unique constraint of invite_codes make
+	if _, exists := repository.database.roles[inviteCode.RoleName]; !exists {
+		return fmt.Errorf("while adding invite code happened error: role %s does not exists", inviteCode.RoleName)
+	}
+
+	for _, storedCode := range repository.database.inviteCodes {
+		if storedCode.CodeHash == inviteCode.CodeHash {
+			return errors.New("while adding invite code happened error: code with the same hash already exists")
+		}
+	}
+
+	repository.database.inviteCodes[inviteCode.Id] = *copyInviteCode(*inviteCode)
+	return nil
+}
+
+func (repository *InMemoryInviteCodeRepository) GetAll(ctx context.Context) ([]*entities.InviteCode, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	inviteCodes := make([]*entities.InviteCode, 0, len(repository.database.inviteCodes))
+	for _, inviteCode := range repository.database.inviteCodes {
+		inviteCodes = append(inviteCodes, copyInviteCode(inviteCode))
+	}
+
+	slices.SortFunc(inviteCodes, func(first *entities.InviteCode, second *entities.InviteCode) int {
+		return second.CreatedAt.Compare(first.CreatedAt)
+	})
+
+	return inviteCodes, nil
+}
+
+func (repository *InMemoryInviteCodeRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	inviteCode, exists := repository.database.inviteCodes[id]
+	if !exists || inviteCode.RevokedAt != nil {
+		return false, nil
+	}
+
+	inviteCode.RevokedAt = &revokedAt
+	repository.database.inviteCodes[id] = inviteCode
+	return true, nil
+}
+
+func (repository *InMemoryInviteCodeRepository) GetUsable(ctx context.Context, codeHash string, roleName string) (*entities.InviteCode, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	inviteCode := repository.findUsable(codeHash, roleName)
+	if inviteCode == nil {
+		return nil, nil
+	}
+
+	return copyInviteCode(*inviteCode), nil
+}
+
+func (repository *InMemoryInviteCodeRepository) Use(ctx context.Context, codeHash string, roleName string) (bool, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	inviteCode := repository.findUsable(codeHash, roleName)
+	if inviteCode == nil {
+		return false, nil
+	}
+
+	inviteCode.UsedCount++
+	repository.database.inviteCodes[inviteCode.Id] = *inviteCode
+	return true, nil
+}
+
+// The same as usableInviteCodeCondition. Must be called under lock of database
+func (repository *InMemoryInviteCodeRepository) findUsable(codeHash string, roleName string) *entities.InviteCode {
+	now := time.Now()
+	for _, inviteCode := range repository.database.inviteCodes {
+		if inviteCode.CodeHash == codeHash && inviteCode.RoleName == roleName && inviteCode.RevokedAt == nil &&
+			(inviteCode.ExpiresAt == nil || inviteCode.ExpiresAt.After(now)) && inviteCode.UsedCount < inviteCode.MaxUses {
+			return &inviteCode
+		}
+	}
+
+	return nil
+}
+
+// Returned code doesn't share optional fields with stored one
+func copyInviteCode(inviteCode entities.InviteCode) *entities.InviteCode {
+	if inviteCode.ExpiresAt != nil {
+		expiresAt := *inviteCode.ExpiresAt
+		inviteCode.ExpiresAt = &expiresAt
+	}
+
+	if inviteCode.RevokedAt != nil {
+		revokedAt := *inviteCode.RevokedAt
+		inviteCode.RevokedAt = &revokedAt
+	}
+
+	return &inviteCode
+}
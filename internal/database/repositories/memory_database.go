@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+// Data of in-memory repositories. Repositories sharing one memoryDatabase see data of each other
+// the same way tables of one database do (e.g. additional roles of users are taken into account when users are listed)
+type memoryDatabase struct {
+	mutex sync.RWMutex
+
+	users map[uuid.UUID]entities.User
+
+	// format: user_id: status, only for deleted users who can restore account
+	statusesBeforeDeletion map[uuid.UUID]string
+
+	// format: name: role, permissions of role are kept in role itself
+	roles map[string]entities.Role
+
+	// format: user_id: [role_name, ...]
+	userRoles map[uuid.UUID][]string
+
+	refreshTokens map[uuid.UUID]entities.RefreshToken
+
+	// format: token_id: revoked_token
+	revokedTokens map[string]entities.RevokedToken
+	userCutoffs   map[uuid.UUID]userTokenCutoff
+
+	signingKeys map[string]entities.SigningKey
+	inviteCodes map[uuid.UUID]entities.InviteCode
+
+	// format: id: message, ids are given in order messages are added
+	outbox              map[int64]entities.OutboxMessage
+	lastOutboxMessageId int64
+}
+
+type userTokenCutoff struct {
+	notBefore time.Time
+	expiresAt time.Time
+}
+
+// Database is seeded with the same roles as migrations seed
+func newMemoryDatabase() *memoryDatabase {
+	database := &memoryDatabase{
+		users:                  make(map[uuid.UUID]entities.User),
+		statusesBeforeDeletion: make(map[uuid.UUID]string),
+		roles:                  make(map[string]entities.Role),
+		userRoles:              make(map[uuid.UUID][]string),
+		refreshTokens:          make(map[uuid.UUID]entities.RefreshToken),
+		revokedTokens:          make(map[string]entities.RevokedToken),
+		userCutoffs:            make(map[uuid.UUID]userTokenCutoff),
+		signingKeys:            make(map[string]entities.SigningKey),
+		inviteCodes:            make(map[uuid.UUID]entities.InviteCode),
+		outbox:                 make(map[int64]entities.OutboxMessage),
+	}
+
+	now := time.Now()
+	seedRoles := []entities.Role{
+		{Name: "Player", IsSelfAssignable: true, RegistrationPolicy: entities.RoleRegistrationOpen},
+		{Name: "Trainer", IsSelfAssignable: true, RegistrationPolicy: entities.RoleRegistrationApproval},
+		{Name: entities.UserRoleAdmin, IsSelfAssignable: false, RegistrationPolicy: entities.RoleRegistrationOpen},
+	}
+
+	for _, role := range seedRoles {
+		role.Permissions = []string{}
+		role.CreatedAt = now
+		role.UpdatedAt = now
+		database.roles[role.Name] = role
+	}
+
+	return database
+}
+
+// TransactionRunner for in-memory repositories. Every call of repository is atomic,
+// but changes made by action before it failed are not rolled back
+type InMemoryTransactionRunner struct{}
+
+func (runner InMemoryTransactionRunner) InTransaction(ctx context.Context, action func(ctx context.Context) error) error {
+	return action(ctx)
+}
@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+)
+
+type OutboxRepository interface {
+	// Fills id of message. Message is written in transaction of ctx, so it's published only if transaction is committed
+	Add(ctx context.Context, message *entities.OutboxMessage) error
+
+	// Returns at most limit messages which are due at moment now: only the oldest not published message of every topic and key,
+	// so the next message of key is returned only after previous one is published or failed.
+	// Returned messages are postponed till claimUntil, so other relays don't take them while they are sent.
+	// If relay stops before marking message, message is returned again after claimUntil
+	ClaimPending(ctx context.Context, now time.Time, claimUntil time.Time, limit int) ([]*entities.OutboxMessage, error)
+
+	MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error
+
+	// Increments amount of attempts and postpones message till nextAttemptAt
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+
+	// Increments amount of attempts and moves message out of queue: it's not published anymore
+	// and doesn't block next messages of its key
+	MarkDead(ctx context.Context, id int64, lastError string, failedAt time.Time) error
+
+	// Returns amount of deleted messages which were published before moment
+	DeleteDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Implementation of OutboxRepository for database/sql + PostgreSQL
+type PgOutboxRepository struct {
+	connection *sql.DB
+}
+
+func NewOutboxRepository(connection *sql.DB) OutboxRepository {
+	return &PgOutboxRepository{connection: connection}
+}
+
+func (repository *PgOutboxRepository) Add(ctx context.Context, message *entities.OutboxMessage) error {
+	addMessageQuery := `INSERT INTO outbox (topic, message_key, payload, created_at, next_attempt_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err := getExecutor(ctx, repository.connection).
+		QueryRowContext(ctx, addMessageQuery, message.Topic, message.Key, message.Payload, message.CreatedAt, message.NextAttemptAt).
+		Scan(&message.Id)
+
+	if err != nil {
+		return fmt.Errorf("while adding message to outbox happened error: %w", err)
+	}
+
+	return nil
+}
+
+// Messages are claimed by one statement, so they are locked only while it runs, not while they are published
+func (repository *PgOutboxRepository) ClaimPending(ctx context.Context, now time.Time, claimUntil time.Time, limit int) ([]*entities.OutboxMessage, error) {
+	claimQuery := `UPDATE outbox SET next_attempt_at = $3
+		WHERE id IN (SELECT id FROM outbox message
+			WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $1
+				AND NOT EXISTS (SELECT 1 FROM outbox earlier
+					WHERE earlier.topic = message.topic AND earlier.message_key = message.message_key
+						AND earlier.delivered_at IS NULL AND earlier.failed_at IS NULL AND earlier.id < message.id)
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, topic, message_key, payload, created_at, attempts, COALESCE(last_error, ''), next_attempt_at`
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, claimQuery, now, limit, claimUntil)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving pending outbox messages happened error: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []*entities.OutboxMessage{}
+	for rows.Next() {
+		message := &entities.OutboxMessage{}
+
+		err = rows.Scan(&message.Id, &message.Topic, &message.Key, &message.Payload, &message.CreatedAt,
+			&message.Attempts, &message.LastError, &message.NextAttemptAt)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving pending outbox messages happened error: %w", err)
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving pending outbox messages happened error: %w", err)
+	}
+
+	// RETURNING doesn't keep order of subquery
+	slices.SortFunc(messages, func(first *entities.OutboxMessage, second *entities.OutboxMessage) int {
+		return cmp.Compare(first.Id, second.Id)
+	})
+
+	return messages, nil
+}
+
+func (repository *PgOutboxRepository) MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error {
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "UPDATE outbox SET delivered_at = $2 WHERE id = $1", id, deliveredAt)
+	if err != nil {
+		return fmt.Errorf("while marking outbox message %d delivered happened error: %w", id, err)
+	}
+
+	return nil
+}
+
+func (repository *PgOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	markFailedQuery := "UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1"
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, markFailedQuery, id, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("while marking outbox message %d failed happened error: %w", id, err)
+	}
+
+	return nil
+}
+
+func (repository *PgOutboxRepository) MarkDead(ctx context.Context, id int64, lastError string, failedAt time.Time) error {
+	markDeadQuery := "UPDATE outbox SET attempts = attempts + 1, last_error = $2, failed_at = $3 WHERE id = $1"
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, markDeadQuery, id, lastError, failedAt)
+	if err != nil {
+		return fmt.Errorf("while marking outbox message %d dead happened error: %w", id, err)
+	}
+
+	return nil
+}
+
+func (repository *PgOutboxRepository) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "DELETE FROM outbox WHERE delivered_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("while deleting delivered outbox messages happened error: %w", err)
+	}
+
+	amountOfDeleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("while deleting delivered outbox messages happened error: %w", err)
+	}
+
+	return amountOfDeleted, nil
+}
+
+// Implementation of OutboxRepository which keeps messages in memory. There is one relay per process,
+// so claiming only postpones messages
+type InMemoryOutboxRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemoryOutboxRepository) Add(ctx context.Context, message *entities.OutboxMessage) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	repository.database.lastOutboxMessageId++
+	message.Id = repository.database.lastOutboxMessageId
+
+	repository.database.outbox[message.Id] = *copyOutboxMessage(*message)
+	return nil
+}
+
+func (repository *InMemoryOutboxRepository) ClaimPending(ctx context.Context, now time.Time, claimUntil time.Time, limit int) ([]*entities.OutboxMessage, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	ids := make([]int64, 0, len(repository.database.outbox))
+	for id, message := range repository.database.outbox {
+		if message.DeliveredAt == nil && message.FailedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	type outboxKey struct {
+		topic string
+		key   string
+	}
+
+	// Only the oldest not published message of key can be returned
+	seenKeys := make(map[outboxKey]struct{})
+	messages := []*entities.OutboxMessage{}
+
+	for _, id := range ids {
+		if len(messages) >= limit {
+			break
+		}
+
+		message := repository.database.outbox[id]
+		key := outboxKey{topic: message.Topic, key: message.Key}
+		if _, isSeen := seenKeys[key]; isSeen {
+			continue
+		}
+		seenKeys[key] = struct{}{}
+
+		if !message.NextAttemptAt.After(now) {
+			messages = append(messages, copyOutboxMessage(message))
+
+			message.NextAttemptAt = claimUntil
+			repository.database.outbox[id] = message
+		}
+	}
+
+	return messages, nil
+}
+
+func (repository *InMemoryOutboxRepository) MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if message, exists := repository.database.outbox[id]; exists {
+		message.DeliveredAt = &deliveredAt
+		repository.database.outbox[id] = message
+	}
+
+	return nil
+}
+
+func (repository *InMemoryOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if message, exists := repository.database.outbox[id]; exists {
+		message.Attempts++
+		message.LastError = lastError
+		message.NextAttemptAt = nextAttemptAt
+		repository.database.outbox[id] = message
+	}
+
+	return nil
+}
+
+func (repository *InMemoryOutboxRepository) MarkDead(ctx context.Context, id int64, lastError string, failedAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if message, exists := repository.database.outbox[id]; exists {
+		message.Attempts++
+		message.LastError = lastError
+		message.FailedAt = &failedAt
+		repository.database.outbox[id] = message
+	}
+
+	return nil
+}
+
+func (repository *InMemoryOutboxRepository) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	var amountOfDeleted int64
+	for id, message := range repository.database.outbox {
+		if message.DeliveredAt != nil && message.DeliveredAt.Before(before) {
+			delete(repository.database.outbox, id)
+			amountOfDeleted++
+		}
+	}
+
+	return amountOfDeleted, nil
+}
+
+// Returned message doesn't share payload and optional fields with stored one
+func copyOutboxMessage(message entities.OutboxMessage) *entities.OutboxMessage {
+	message.Payload = slices.Clone(message.Payload)
+
+	if message.DeliveredAt != nil {
+		deliveredAt := *message.DeliveredAt
+		message.DeliveredAt = &deliveredAt
+	}
+
+	if message.FailedAt != nil {
+		failedAt := *message.FailedAt
+		message.FailedAt = &failedAt
+	}
+
+	return &message
+}
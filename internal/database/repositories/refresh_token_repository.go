@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepository interface {
+	Add(ctx context.Context, token *entities.RefreshToken) error
+
+	// If token does not exists - returns nil, nil
+	GetByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+
+	// Marks token as revoked only if it wasn't revoked yet. Returns false if token was already revoked
+	Revoke(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID) (bool, error)
+
+	RevokeFamily(ctx context.Context, familyId uuid.UUID) error
+
+	// Revokes all not revoked tokens of user
+	RevokeAllForUser(ctx context.Context, userId uuid.UUID) error
+
+	// All tokens of user (including revoked), newest first
+	GetByUser(ctx context.Context, userId uuid.UUID) ([]entities.RefreshToken, error)
+}
+
+// Implementation of RefreshTokenRepository for database/sql + PostgreSQL
+type PgRefreshTokenRepository struct {
+	connection *sql.DB
+}
+
+func NewRefreshTokenRepository(connection *sql.DB) RefreshTokenRepository {
+	return &PgRefreshTokenRepository{connection: connection}
+}
+
+func (repository *PgRefreshTokenRepository) Add(ctx context.Context, token *entities.RefreshToken) error {
+	addTokenQuery := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, addTokenQuery, token.Id, token.UserId, token.FamilyId, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("while adding refresh token happened error: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *PgRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	tokenQuery := `SELECT id, user_id, family_id, token_hash, created_at, expires_at, revoked_at, replaced_by
+		FROM refresh_tokens WHERE token_hash = $1`
+
+	token := &entities.RefreshToken{}
+	var revokedAt sql.NullTime
+	var replacedBy uuid.NullUUID
+
+	err := getExecutor(ctx, repository.connection).QueryRowContext(ctx, tokenQuery, tokenHash).Scan(
+		&token.Id, &token.UserId, &token.FamilyId, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt, &revokedAt, &replacedBy)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving refresh token happened error: %w", err)
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	if replacedBy.Valid {
+		token.ReplacedBy = &replacedBy.UUID
+	}
+
+	return token, nil
+}
+
+func (repository *PgRefreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID) (bool, error) {
+	revokeQuery := "UPDATE refresh_tokens SET revoked_at = $2, replaced_by = $3 WHERE id = $1 AND revoked_at IS NULL"
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, revokeQuery, id, time.Now(), replacedBy)
+	if err != nil {
+		return false, fmt.Errorf("while revoking refresh token %s happened error: %w", id, err)
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("while revoking refresh token %s happened error: %w", id, err)
+	}
+
+	return affectedRows == 1, nil
+}
+
+func (repository *PgRefreshTokenRepository) RevokeFamily(ctx context.Context, familyId uuid.UUID) error {
+	revokeQuery := "UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL"
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, revokeQuery, familyId, time.Now())
+	if err != nil {
+		return fmt.Errorf("while revoking refresh token family %s happened error: %w", familyId, err)
+	}
+
+	return nil
+}
+
+func (repository *PgRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userId uuid.UUID) error {
+	revokeQuery := "UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL"
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, revokeQuery, userId, time.Now())
+	if err != nil {
+		return fmt.Errorf("while revoking refresh tokens of user %s happened error: %w", userId, err)
+	}
+
+	return nil
+}
+
+func (repository *PgRefreshTokenRepository) GetByUser(ctx context.Context, userId uuid.UUID) ([]entities.RefreshToken, error) {
+	tokensQuery := `SELECT id, user_id, family_id, token_hash, created_at, expires_at, revoked_at, replaced_by
+		FROM refresh_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, tokensQuery, userId)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving refresh tokens of user %s happened error: %w", userId, err)
+	}
+	defer rows.Close()
+
+	tokens := []entities.RefreshToken{}
+	for rows.Next() {
+		token := entities.RefreshToken{}
+		var revokedAt sql.NullTime
+		var replacedBy uuid.NullUUID
+
+		err = rows.Scan(&token.Id, &token.UserId, &token.FamilyId, &token.TokenHash,
+			&token.CreatedAt, &token.ExpiresAt, &revokedAt, &replacedBy)
+
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving refresh tokens of user %s happened error: %w", userId, err)
+		}
+
+		if revokedAt.Valid {
+			token.RevokedAt = &revokedAt.Time
+		}
+
+		if replacedBy.Valid {
+			token.ReplacedBy = &replacedBy.UUID
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving refresh tokens of user %s happened error: %w", userId, err)
+	}
+
+	return tokens, nil
+}
+
+// Implementation of RefreshTokenRepository which keeps tokens in memory
+type InMemoryRefreshTokenRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemoryRefreshTokenRepository) Add(ctx context.Context, token *entities.RefreshToken) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	for _, storedToken := range repository.database.refreshTokens {
+		if storedToken.TokenHash == token.TokenHash {
+			return errors.New("while adding refresh token happened error: token with the same hash already exists")
+		}
+	}
+
+	repository.database.refreshTokens[token.Id] = *copyRefreshToken(*token)
+	return nil
+}
+
+func (repository *InMemoryRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	for _, token := range repository.database.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return copyRefreshToken(token), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repository *InMemoryRefreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID) (bool, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	token, exists := repository.database.refreshTokens[id]
+	if !exists || token.RevokedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	if replacedBy != nil {
+		replacedById := *replacedBy
+		token.ReplacedBy = &replacedById
+	}
+
+	repository.database.refreshTokens[id] = token
+	return true, nil
+}
+
+func (repository *InMemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyId uuid.UUID) error {
+	repository.revokeWhere(func(token entities.RefreshToken) bool { return token.FamilyId == familyId })
+	return nil
+}
+
+func (repository *InMemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userId uuid.UUID) error {
+	repository.revokeWhere(func(token entities.RefreshToken) bool { return token.UserId == userId })
+	return nil
+}
+
+func (repository *InMemoryRefreshTokenRepository) GetByUser(ctx context.Context, userId uuid.UUID) ([]entities.RefreshToken, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	tokens := []entities.RefreshToken{}
+	for _, token := range repository.database.refreshTokens {
+		if token.UserId == userId {
+			tokens = append(tokens, *copyRefreshToken(token))
+		}
+	}
+
+	slices.SortFunc(tokens, func(first entities.RefreshToken, second entities.RefreshToken) int {
+		return second.CreatedAt.Compare(first.CreatedAt)
+	})
+
+	return tokens, nil
+}
+
+// Revokes not revoked tokens matching condition
+func (repository *InMemoryRefreshTokenRepository) revokeWhere(condition func(token entities.RefreshToken) bool) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	now := time.Now()
+	for id, token := range repository.database.refreshTokens {
+		if token.RevokedAt == nil && condition(token) {
+			token.RevokedAt = &now
+			repository.database.refreshTokens[id] = token
+		}
+	}
+}
+
+// Returned token doesn't share optional fields with stored one
+func copyRefreshToken(token entities.RefreshToken) *entities.RefreshToken {
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		token.RevokedAt = &revokedAt
+	}
+
+	if token.ReplacedBy != nil {
+		replacedBy := *token.ReplacedBy
+		token.ReplacedBy = &replacedBy
+	}
+
+	return &token
+}
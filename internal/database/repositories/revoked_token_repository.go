@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+type RevokedTokenRepository interface {
+	// Adding already revoked token is not an error
+	Add(ctx context.Context, token *entities.RevokedToken) error
+
+	Exists(ctx context.Context, tokenId string) (bool, error)
+
+	// Makes all tokens of user issued before notBefore revoked. Cutoff only moves forward
+	SetUserCutoff(ctx context.Context, userId uuid.UUID, notBefore time.Time, expiresAt time.Time) error
+
+	// Returns zero time if tokens of user were never revoked all at once
+	GetUserCutoff(ctx context.Context, userId uuid.UUID) (time.Time, error)
+
+	// Deletes revoked tokens and user cutoffs which are expired
+	DeleteExpired(ctx context.Context) error
+}
+
+// Implementation of RevokedTokenRepository for database/sql + PostgreSQL
+type PgRevokedTokenRepository struct {
+	connection *sql.DB
+}
+
+func NewRevokedTokenRepository(connection *sql.DB) RevokedTokenRepository {
+	return &PgRevokedTokenRepository{connection: connection}
+}
+
+func (repository *PgRevokedTokenRepository) Add(ctx context.Context, token *entities.RevokedToken) error {
+	addTokenQuery := `INSERT INTO revoked_tokens (token_id, revoked_at, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (token_id) DO NOTHING`
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, addTokenQuery, token.TokenId, token.RevokedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("while adding revoked token %s happened error: %w", token.TokenId, err)
+	}
+
+	return nil
+}
+
+func (repository *PgRevokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	var exists bool
+	existsQuery := "SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE token_id = $1)"
+
+	err := getExecutor(ctx, repository.connection).QueryRowContext(ctx, existsQuery, tokenId).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("while checking if token %s is revoked happened error: %w", tokenId, err)
+	}
+
+	return exists, nil
+}
+
+func (repository *PgRevokedTokenRepository) SetUserCutoff(ctx context.Context, userId uuid.UUID, notBefore time.Time, expiresAt time.Time) error {
+	setCutoffQuery := `INSERT INTO user_token_cutoffs (user_id, not_before, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			not_before = GREATEST(user_token_cutoffs.not_before, EXCLUDED.not_before),
+			expires_at = GREATEST(user_token_cutoffs.expires_at, EXCLUDED.expires_at)`
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, setCutoffQuery, userId, notBefore, expiresAt)
+	if err != nil {
+		return fmt.Errorf("while revoking tokens of user %s happened error: %w", userId, err)
+	}
+
+	return nil
+}
+
+func (repository *PgRevokedTokenRepository) GetUserCutoff(ctx context.Context, userId uuid.UUID) (time.Time, error) {
+	var notBefore time.Time
+	cutoffQuery := "SELECT not_before FROM user_token_cutoffs WHERE user_id = $1"
+
+	err := getExecutor(ctx, repository.connection).QueryRowContext(ctx, cutoffQuery, userId).Scan(&notBefore)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("while retrieving token cutoff of user %s happened error: %w", userId, err)
+	}
+
+	return notBefore, nil
+}
+
+func (repository *PgRevokedTokenRepository) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", now)
+	if err != nil {
+		return fmt.Errorf("while deleting expired revoked tokens happened error: %w", err)
+	}
+
+	_, err = getExecutor(ctx, repository.connection).ExecContext(ctx, "DELETE FROM user_token_cutoffs WHERE expires_at <= $1", now)
+	if err != nil {
+		return fmt.Errorf("while deleting expired user token cutoffs happened error: %w", err)
+	}
+
+	return nil
+}
+
+// Implementation of RevokedTokenRepository which keeps revocations in memory
+type InMemoryRevokedTokenRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemoryRevokedTokenRepository) Add(ctx context.Context, token *entities.RevokedToken) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if _, exists := repository.database.revokedTokens[token.TokenId]; !exists {
+		repository.database.revokedTokens[token.TokenId] = *token
+	}
+
+	return nil
+}
+
+func (repository *InMemoryRevokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	_, exists := repository.database.revokedTokens[tokenId]
+	return exists, nil
+}
+
+func (repository *InMemoryRevokedTokenRepository) SetUserCutoff(ctx context.Context, userId uuid.UUID, notBefore time.Time, expiresAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	cutoff, exists := repository.database.userCutoffs[userId]
+	if !exists {
+		repository.database.userCutoffs[userId] = userTokenCutoff{notBefore: notBefore, expiresAt: expiresAt}
+		return nil
+	}
+
+	if notBefore.After(cutoff.notBefore) {
+		cutoff.notBefore = notBefore
+	}
+
+	if expiresAt.After(cutoff.expiresAt) {
+		cutoff.expiresAt = expiresAt
+	}
+
+	repository.database.userCutoffs[userId] = cutoff
+	return nil
+}
+
+func (repository *InMemoryRevokedTokenRepository) GetUserCutoff(ctx context.Context, userId uuid.UUID) (time.Time, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	return repository.database.userCutoffs[userId].notBefore, nil
+}
+
+func (repository *InMemoryRevokedTokenRepository) DeleteExpired(ctx context.Context) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	now := time.Now()
+	for tokenId, token := range repository.database.revokedTokens {
+		if !token.ExpiresAt.After(now) {
+			delete(repository.database.revokedTokens, tokenId)
+		}
+	}
+
+	for userId, cutoff := range repository.database.userCutoffs {
+		if !cutoff.expiresAt.After(now) {
+			delete(repository.database.userCutoffs, userId)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RoleRepository interface {
+	// Returns all roles with their permissions, sorted by name
+	GetAll(ctx context.Context) ([]*entities.Role, error)
+
+	// Returns ErrRoleExists if role with the same name already exists
+	Add(ctx context.Context, role *entities.Role) error
+
+	// Saves description, permissions and registration settings of role. Returns false if role does not exists
+	Update(ctx context.Context, role *entities.Role) (bool, error)
+
+	// Returns ErrRoleInUse if role is primary role of some user and false if role does not exists.
+	// Role is taken away from users who have it as additional role
+	Delete(ctx context.Context, name string) (bool, error)
+
+	// Additional roles of user (without primary role from users.user_role)
+	GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error)
+
+	// Replaces additional roles of user
+	SetUserRoles(ctx context.Context, userId uuid.UUID, roleNames []string) error
+}
+
+var ErrRoleExists = errors.New("role with this name already exists")
+var ErrRoleInUse = errors.New("role is primary role of some users")
+
+// Implementation of RoleRepository for database/sql + PostgreSQL
+type PgRoleRepository struct {
+	connection *sql.DB
+}
+
+func NewRoleRepository(connection *sql.DB) RoleRepository {
+	return &PgRoleRepository{connection: connection}
+}
+
+func (repository *PgRoleRepository) GetAll(ctx context.Context) ([]*entities.Role, error) {
+	rolesQuery := `SELECT roles.name, roles.description, roles.is_self_assignable, roles.registration_policy, roles.created_at, roles.updated_at,
+			COALESCE(array_agg(role_permissions.permission ORDER BY role_permissions.permission)
+				FILTER (WHERE role_permissions.permission IS NOT NULL), '{}')
+		FROM roles LEFT JOIN role_permissions ON role_permissions.role_name = roles.name
+		GROUP BY roles.name
+		ORDER BY roles.name`
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, rolesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving roles happened error: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []*entities.Role{}
+	for rows.Next() {
+		role := &entities.Role{}
+		var permissions pq.StringArray
+
+		err = rows.Scan(&role.Name, &role.Description, &role.IsSelfAssignable, &role.RegistrationPolicy, &role.CreatedAt, &role.UpdatedAt, &permissions)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving roles happened error: %w", err)
+		}
+
+		role.Permissions = permissions
+		roles = append(roles, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving roles happened error: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (repository *PgRoleRepository) Add(ctx context.Context, role *entities.Role) error {
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+
+	return RunInTransaction(ctx, repository.connection, func(ctx context.Context) error {
+		transaction := getExecutor(ctx, repository.connection)
+
+		addRoleQuery := `INSERT INTO roles (name, description, is_self_assignable, registration_policy, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (name) DO NOTHING`
+
+		result, err := transaction.ExecContext(ctx, addRoleQuery, role.Name, role.Description, role.IsSelfAssignable, role.RegistrationPolicy, role.CreatedAt, role.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("while adding role %s happened error: %w", role.Name, err)
+		}
+
+		affectedRows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("while adding role %s happened error: %w", role.Name, err)
+		}
+
+		if affectedRows == 0 {
+			return ErrRoleExists
+		}
+
+		return insertRolePermissions(ctx, transaction, role)
+	})
+}
+
+func (repository *PgRoleRepository) Update(ctx context.Context, role *entities.Role) (bool, error) {
+	role.UpdatedAt = time.Now()
+	isUpdated := false
+
+	err := RunInTransaction(ctx, repository.connection, func(ctx context.Context) error {
+		transaction := getExecutor(ctx, repository.connection)
+
+		updateRoleQuery := "UPDATE roles SET description = $2, is_self_assignable = $3, registration_policy = $4, updated_at = $5 WHERE name = $1"
+
+		result, err := transaction.ExecContext(ctx, updateRoleQuery, role.Name, role.Description, role.IsSelfAssignable, role.RegistrationPolicy, role.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("while updating role %s happened error: %w", role.Name, err)
+		}
+
+		affectedRows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("while updating role %s happened error: %w", role.Name, err)
+		}
+
+		if affectedRows == 0 {
+			return nil
+		}
+
+		_, err = transaction.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_name = $1", role.Name)
+		if err != nil {
+			return fmt.Errorf("while updating permissions of role %s happened error: %w", role.Name, err)
+		}
+
+		isUpdated = true
+		return insertRolePermissions(ctx, transaction, role)
+	})
+
+	return isUpdated, err
+}
+
+func (repository *PgRoleRepository) Delete(ctx context.Context, name string) (bool, error) {
+	// Usage is checked by the same statement which deletes role
+	deleteQuery := "DELETE FROM roles WHERE name = $1 AND NOT EXISTS (SELECT 1 FROM users WHERE user_role = $1)"
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, deleteQuery, name)
+	if err != nil {
+		return false, fmt.Errorf("while deleting role %s happened error: %w", name, err)
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("while deleting role %s happened error: %w", name, err)
+	}
+
+	if affectedRows == 1 {
+		return true, nil
+	}
+
+	var exists bool
+	err = getExecutor(ctx, repository.connection).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("while deleting role %s happened error: %w", name, err)
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	return false, ErrRoleInUse
+}
+
+func (repository *PgRoleRepository) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, "SELECT role_name FROM user_roles WHERE user_id = $1 ORDER BY role_name", userId)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving roles of user %s happened error: %w", userId, err)
+	}
+	defer rows.Close()
+
+	roleNames := []string{}
+	for rows.Next() {
+		var roleName string
+		err = rows.Scan(&roleName)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving roles of user %s happened error: %w", userId, err)
+		}
+
+		roleNames = append(roleNames, roleName)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving roles of user %s happened error: %w", userId, err)
+	}
+
+	return roleNames, nil
+}
+
+func (repository *PgRoleRepository) SetUserRoles(ctx context.Context, userId uuid.UUID, roleNames []string) error {
+	return RunInTransaction(ctx, repository.connection, func(ctx context.Context) error {
+		transaction := getExecutor(ctx, repository.connection)
+
+		_, err := transaction.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", userId)
+		if err != nil {
+			return fmt.Errorf("while setting roles of user %s happened error: %w", userId, err)
+		}
+
+		now := time.Now()
+		for _, roleName := range roleNames {
+			_, err = transaction.ExecContext(ctx, "INSERT INTO user_roles (user_id, role_name, granted_at) VALUES ($1, $2, $3)", userId, roleName, now)
+			if err != nil {
+				return fmt.Errorf("while setting roles of user %s happened error: %w", userId, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func insertRolePermissions(ctx context.Context, transaction queryExecutor, role *entities.Role) error {
+	for _, permission := range role.Permissions {
+		_, err := transaction.ExecContext(ctx, "INSERT INTO role_permissions (role_name, permission) VALUES ($1, $2)", role.Name, permission)
+		if err != nil {
+			return fmt.Errorf("while saving permissions of role %s happened error: %w", role.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Implementation of RoleRepository which keeps roles in memory
+type InMemoryRoleRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemoryRoleRepository) GetAll(ctx context.Context) ([]*entities.Role, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	roles := make([]*entities.Role, 0, len(repository.database.roles))
+	for _, role := range repository.database.roles {
+		roles = append(roles, copyRole(role))
+	}
+
+	slices.SortFunc(roles, func(first *entities.Role, second *entities.Role) int { return strings.Compare(first.Name, second.Name) })
+
+	return roles, nil
+}
+
+func (repository *InMemoryRoleRepository) Add(ctx context.Context, role *entities.Role) error {
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if _, exists := repository.database.roles[role.Name]; exists {
+		return ErrRoleExists
+	}
+
+	repository.database.roles[role.Name] = *copyRole(*role)
+	return nil
+}
+
+func (repository *InMemoryRoleRepository) Update(ctx context.Context, role *entities.Role) (bool, error) {
+	role.UpdatedAt = time.Now()
+
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	storedRole, exists := repository.database.roles[role.Name]
+	if !exists {
+		return false, nil
+	}
+
+	updatedRole := *copyRole(*role)
+	updatedRole.CreatedAt = storedRole.CreatedAt
+
+	repository.database.roles[role.Name] = updatedRole
+	return true, nil
+}
+
+func (repository *InMemoryRoleRepository) Delete(ctx context.Context, name string) (bool, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if _, exists := repository.database.roles[name]; !exists {
+		return false, nil
+	}
+
+	for _, user := range repository.database.users {
+		if user.UserRole == name {
+			return false, ErrRoleInUse
+		}
+	}
+
+	delete(repository.database.roles, name)
+
+	// The same as ON DELETE CASCADE of tables referencing roles
+	for userId, roleNames := range repository.database.userRoles {
+		repository.database.userRoles[userId] = slices.DeleteFunc(roleNames, func(roleName string) bool { return roleName == name })
+	}
+
+	for id, inviteCode := range repository.database.inviteCodes {
+		if inviteCode.RoleName == name {
+			delete(repository.database.inviteCodes, id)
+		}
+	}
+
+	return true, nil
+}
+
+func (repository *InMemoryRoleRepository) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	roleNames := slices.Clone(repository.database.userRoles[userId])
+	if roleNames == nil {
+		roleNames = []string{}
+	}
+
+	slices.Sort(roleNames)
+	return roleNames, nil
+}
+
+func (repository *InMemoryRoleRepository) SetUserRoles(ctx context.Context, userId uuid.UUID, roleNames []string) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	// The same checks as foreign keys of user_roles make
+	if _, exists := repository.database.users[userId]; !exists {
+		return fmt.Errorf("while setting roles of user %s happened error: user does not exists", userId)
+	}
+
+	for _, roleName := range roleNames {
+		if _, exists := repository.database.roles[roleName]; !exists {
+			return fmt.Errorf("while setting roles of user %s happened error: role %s does not exists", userId, roleName)
+		}
+	}
+
+	repository.database.userRoles[userId] = slices.Clone(roleNames)
+	return nil
+}
+
+// Returned role doesn't share permissions with stored one
+func copyRole(role entities.Role) *entities.Role {
+	role.Permissions = slices.Clone(role.Permissions)
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	slices.Sort(role.Permissions)
+	return &role
+}
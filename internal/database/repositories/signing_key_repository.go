@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/entities"
+)
+
+type SigningKeyRepository interface {
+	Add(ctx context.Context, key *entities.SigningKey) error
+
+	// Returns not expired keys of algorithm, newest first
+	GetActive(ctx context.Context, algorithm string) ([]entities.SigningKey, error)
+
+	// Retires all keys of algorithm except passed one, retired keys expire at expiresAt
+	RetireAllExcept(ctx context.Context, algorithm string, keyId string, expiresAt time.Time) error
+
+	DeleteExpired(ctx context.Context) error
+}
+
+// Implementation of SigningKeyRepository for database/sql + PostgreSQL
+type PgSigningKeyRepository struct {
+	connection *sql.DB
+}
+
+func NewSigningKeyRepository(connection *sql.DB) SigningKeyRepository {
+	return &PgSigningKeyRepository{connection: connection}
+}
+
+func (repository *PgSigningKeyRepository) Add(ctx context.Context, key *entities.SigningKey) error {
+	addKeyQuery := "INSERT INTO signing_keys (id, algorithm, private_key, created_at) VALUES ($1, $2, $3, $4)"
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, addKeyQuery, key.Id, key.Algorithm, key.PrivateKey, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("while adding signing key happened error: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *PgSigningKeyRepository) GetActive(ctx context.Context, algorithm string) ([]entities.SigningKey, error) {
+	keysQuery := `SELECT id, algorithm, private_key, created_at, retired_at, expires_at FROM signing_keys
+		WHERE algorithm = $1 AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY created_at DESC`
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, keysQuery, algorithm, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving signing keys happened error: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []entities.SigningKey{}
+	for rows.Next() {
+		key := entities.SigningKey{}
+		var retiredAt, expiresAt sql.NullTime
+
+		err = rows.Scan(&key.Id, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &retiredAt, &expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("while retrieving signing keys happened error: %w", err)
+		}
+
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.Time
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while retrieving signing keys happened error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (repository *PgSigningKeyRepository) RetireAllExcept(ctx context.Context, algorithm string, keyId string, expiresAt time.Time) error {
+	retireQuery := `UPDATE signing_keys SET retired_at = $3, expires_at = $4
+		WHERE algorithm = $1 AND id <> $2 AND retired_at IS NULL`
+
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, retireQuery, algorithm, keyId, time.Now(), expiresAt)
+	if err != nil {
+		return fmt.Errorf("while retiring signing keys happened error: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *PgSigningKeyRepository) DeleteExpired(ctx context.Context) error {
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "DELETE FROM signing_keys WHERE expires_at <= $1", time.Now())
+	if err != nil {
+		return fmt.Errorf("while deleting expired signing keys happened error: %w", err)
+	}
+
+	return nil
+}
+
+// Implementation of SigningKeyRepository which keeps keys in memory, so they don't outlive process
+type InMemorySigningKeyRepository struct {
+	database *memoryDatabase
+}
+
+func (repository *InMemorySigningKeyRepository) Add(ctx context.Context, key *entities.SigningKey) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if _, exists := repository.database.signingKeys[key.Id]; exists {
+		return fmt.Errorf("while adding signing key happened error: key %s already exists", key.Id)
+	}
+
+	repository.database.signingKeys[key.Id] = *copySigningKey(*key)
+	return nil
+}
+
+func (repository *InMemorySigningKeyRepository) GetActive(ctx context.Context, algorithm string) ([]entities.SigningKey, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	now := time.Now()
+	keys := []entities.SigningKey{}
+	for _, key := range repository.database.signingKeys {
+		if key.Algorithm == algorithm && (key.ExpiresAt == nil || key.ExpiresAt.After(now)) {
+			keys = append(keys, *copySigningKey(key))
+		}
+	}
+
+	slices.SortFunc(keys, func(first entities.SigningKey, second entities.SigningKey) int {
+		return second.CreatedAt.Compare(first.CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (repository *InMemorySigningKeyRepository) RetireAllExcept(ctx context.Context, algorithm string, keyId string, expiresAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	now := time.Now()
+	for id, key := range repository.database.signingKeys {
+		if key.Algorithm != algorithm || id == keyId || key.RetiredAt != nil {
+			continue
+		}
+
+		retiredAt := now
+		keyExpiresAt := expiresAt
+		key.RetiredAt = &retiredAt
+		key.ExpiresAt = &keyExpiresAt
+
+		repository.database.signingKeys[id] = key
+	}
+
+	return nil
+}
+
+func (repository *InMemorySigningKeyRepository) DeleteExpired(ctx context.Context) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	now := time.Now()
+	for id, key := range repository.database.signingKeys {
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+			delete(repository.database.signingKeys, id)
+		}
+	}
+
+	return nil
+}
+
+// Returned key doesn't share private key and optional fields with stored one
+func copySigningKey(key entities.SigningKey) *entities.SigningKey {
+	key.PrivateKey = slices.Clone(key.PrivateKey)
+
+	if key.RetiredAt != nil {
+		retiredAt := *key.RetiredAt
+		key.RetiredAt = &retiredAt
+	}
+
+	if key.ExpiresAt != nil {
+		expiresAt := *key.ExpiresAt
+		key.ExpiresAt = &expiresAt
+	}
+
+	return &key
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import "github.com/WebChads/AuthService/internal/phone"
+
+// All repositories service works with, backed by the same storage
+type Storage struct {
+	Users         UserRepository
+	Roles         RoleRepository
+	RefreshTokens RefreshTokenRepository
+	RevokedTokens RevokedTokenRepository
+	SigningKeys   SigningKeyRepository
+	InviteCodes   InviteCodeRepository
+	Outbox        OutboxRepository
+
+	// Runs actions of several repositories in one transaction
+	Transactions TransactionRunner
+}
+
+// Storage which lives only while process runs, for local run and tests without PostgreSQL
+func NewInMemoryStorage(phoneParser phone.Parser) *Storage {
+	database := newMemoryDatabase()
+
+	return &Storage{
+		Users:         &InMemoryUserRepository{database: database, phoneParser: phoneParser},
+		Roles:         &InMemoryRoleRepository{database: database},
+		RefreshTokens: &InMemoryRefreshTokenRepository{database: database},
+		RevokedTokens: &InMemoryRevokedTokenRepository{database: database},
+		SigningKeys:   &InMemorySigningKeyRepository{database: database},
+		InviteCodes:   &InMemoryInviteCodeRepository{database: database},
+		Outbox:        &InMemoryOutboxRepository{database: database},
+		Transactions:  InMemoryTransactionRunner{},
+	}
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Unit of work: repositories called with context passed to action take part in one transaction,
+// which is committed if action succeeds and rolled back otherwise
+type TransactionRunner interface {
+	InTransaction(ctx context.Context, action func(ctx context.Context) error) error
+}
+
+type transactionKey struct{}
+
+// Common part of *sql.DB and *sql.Tx
+type queryExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Runs action in transaction on connection. If context already carries transaction, action joins it
+func RunInTransaction(ctx context.Context, connection *sql.DB, action func(ctx context.Context) error) error {
+	if _, isInTransaction := ctx.Value(transactionKey{}).(*sql.Tx); isInTransaction {
+		return action(ctx)
+	}
+
+	transaction, err := connection.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("while starting transaction happened error: %w", err)
+	}
+
+	err = action(context.WithValue(ctx, transactionKey{}, transaction))
+	if err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return fmt.Errorf("while committing transaction happened error: %w", err)
+	}
+
+	return nil
+}
+
+// Transaction carried by context or connection itself if there is no transaction
+func getExecutor(ctx context.Context, connection *sql.DB) queryExecutor {
+	if transaction, isInTransaction := ctx.Value(transactionKey{}).(*sql.Tx); isInTransaction {
+		return transaction
+	}
+
+	return connection
+}
+
+func isUniqueViolation(err error) bool {
+	var pqError *pq.Error
+	return errors.As(err, &pqError) && pqError.Code == "23505"
+}
@@ -1,62 +1,134 @@
 package repositories
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/phone"
+	"github.com/google/uuid"
 )
 
 type UserRepository interface {
-	Add(user *entities.User) error
+	// Returns ErrUserAlreadyExists if phone number already belongs to some user
+	Add(ctx context.Context, user *entities.User) error
 
-	// If user does not exists - returns nil, nil
-	Get(phoneNumber string) (*entities.User, error)
+	// Returns ErrUserNotFound if user does not exists
+	Get(ctx context.Context, phoneNumber string) (*entities.User, error)
 
-	Count(phoneNumber string) (int, error)
+	// Returns ErrUserNotFound if user does not exists
+	GetById(ctx context.Context, id uuid.UUID) (*entities.User, error)
+
+	Count(ctx context.Context, phoneNumber string) (int, error)
+
+	// Saves only fields which user edits on their own (display name) and sets updated_at, so role and status
+	// changed by admin concurrently are not overwritten. Returns ErrUserNotFound if user does not exists or deleted
+	UpdateProfile(ctx context.Context, user *entities.User) error
+
+	// Changes only status of user, so profile edited concurrently is not overwritten.
+	// Deletion has its own method MarkDeleted. Returns ErrUserNotFound if user does not exists or deleted
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, updatedAt time.Time) error
+
+	// Changes only primary role of user. Returns ErrUserNotFound if user does not exists or deleted
+	UpdateRole(ctx context.Context, id uuid.UUID, role string, updatedAt time.Time) error
+
+	UpdateLastLogin(ctx context.Context, id uuid.UUID, lastLoginAt time.Time) error
+
+	// Returns ErrPhoneNumberTaken if number belongs to another user and ErrUserNotFound if user does not exists
+	UpdatePhoneNumber(ctx context.Context, id uuid.UUID, phoneNumber string) error
+
+	// Soft delete: sets status "deleted" and deleted_at. Restorable deletion can be undone by Restore until user is purged.
+	// Returns ErrUserNotFound if user does not exists or already deleted
+	MarkDeleted(ctx context.Context, id uuid.UUID, deletedAt time.Time, isRestorable bool) error
+
+	// Returns user to status it had before restorable deletion and clears deleted_at.
+	// Returns ErrUserNotFound if user does not exists, isn't deleted or its deletion can't be undone
+	Restore(ctx context.Context, id uuid.UUID, restoredAt time.Time) error
+
+	// Hard delete of users marked deleted before passed time, returns ids of purged users
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+
+	// Returns page of users matching filter (sorted from newest) and total amount of matching users
+	List(ctx context.Context, filter UserFilter) ([]*entities.User, int, error)
+}
+
+// Empty fields are not filtered by
+type UserFilter struct {
+	// Primary or additional role
+	Role   string
+	Status string
+
+	// Beginning of phone number in E.164 format, e.g. "+7912"
+	PhoneNumberPrefix string
+
+	Offset int
+	Limit  int
 }
 
-// Implementation of UserRepository for database/sql + PostgreSQL
+var ErrUserNotFound = errors.New("user does not exists")
+var ErrUserAlreadyExists = errors.New("user with this phone number already exists")
+var ErrPhoneNumberTaken = errors.New("phone number already belongs to another user")
+
+const userColumns = "id, phone_number, user_role, display_name, status, created_at, updated_at, last_login_at, deleted_at"
+
+// Implementation of UserRepository for database/sql + PostgreSQL.
+// Phone numbers are stored in E.164 format, so any format of the same number finds the same user
 type PgUserRepository struct {
-	connection *sql.DB
+	connection  *sql.DB
+	phoneParser phone.Parser
 }
 
-func NewUserRepository(connection *sql.DB) UserRepository {
-	return &PgUserRepository{connection: connection}
+func NewUserRepository(connection *sql.DB, phoneParser phone.Parser) UserRepository {
+	return &PgUserRepository{connection: connection, phoneParser: phoneParser}
 }
 
-func (repository *PgUserRepository) Add(user *entities.User) error {
-	amountOfUsersWithThisPhoneNumber, err := repository.Count(user.PhoneNumber)
+func (repository *PgUserRepository) Add(ctx context.Context, user *entities.User) error {
+	phoneNumber, err := repository.phoneParser.Normalize(user.PhoneNumber)
 	if err != nil {
 		return fmt.Errorf("while adding new user happened error: %w", err)
 	}
+	user.PhoneNumber = phoneNumber
 
-	if amountOfUsersWithThisPhoneNumber != 0 {
-		return errors.New("while adding new user happened error: there are already user with that phone number")
+	now := time.Now()
+	if user.Status == "" {
+		user.Status = entities.UserStatusActive
 	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
 
-	addUserQuery := "INSERT INTO users VALUES ($1, $2, $3)"
-	_, err = repository.connection.Exec(addUserQuery, user.Id, user.PhoneNumber, user.UserRole)
+	addUserQuery := `INSERT INTO users (id, phone_number, user_role, display_name, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
-	return err
+	// Uniqueness of phone number is guaranteed by unique index, so concurrent registrations can't both succeed
+	_, err = getExecutor(ctx, repository.connection).ExecContext(ctx, addUserQuery, user.Id, user.PhoneNumber, user.UserRole, user.DisplayName, user.Status, user.CreatedAt, user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+
+	if err != nil {
+		return fmt.Errorf("while adding new user happened error: %w", err)
+	}
+
+	return nil
 }
 
-func (repository *PgUserRepository) Get(phoneNumber string) (*entities.User, error) {
-	countUsers, err := repository.Count(phoneNumber)
+func (repository *PgUserRepository) Get(ctx context.Context, phoneNumber string) (*entities.User, error) {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("while retrieving user with phone number happened error: %w", err)
 	}
 
-	if countUsers == 0 {
-		return nil, nil
-	} else if countUsers > 1 {
-		return nil, fmt.Errorf("while retrieving user with phone number %s happened error: there are more than one user with that phone number", phoneNumber)
+	user, err := scanUser(getExecutor(ctx, repository.connection).QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE phone_number = $1", phoneNumber))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
 	}
 
-	user := &entities.User{}
-	userQuery := "SELECT id, phone_number, user_role FROM users WHERE phone_number = $1"
-	err = repository.connection.QueryRow(userQuery, phoneNumber).Scan(&user.Id, &user.PhoneNumber, &user.UserRole)
 	if err != nil {
 		return nil, fmt.Errorf("while retrieving user with phone number %s happened error: %w", phoneNumber, err)
 	}
@@ -64,14 +136,553 @@ func (repository *PgUserRepository) Get(phoneNumber string) (*entities.User, err
 	return user, nil
 }
 
-func (repository *PgUserRepository) Count(phoneNumber string) (int, error) {
+func (repository *PgUserRepository) GetById(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	user, err := scanUser(getExecutor(ctx, repository.connection).QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving user with id %s happened error: %w", id, err)
+	}
+
+	return user, nil
+}
+
+func (repository *PgUserRepository) Count(ctx context.Context, phoneNumber string) (int, error) {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return 0, fmt.Errorf("while counting amount of users with phone number happened error: %w", err)
+	}
+
 	countQuery := "SELECT COUNT(*) FROM users WHERE phone_number = $1"
 
 	var amountOfUsersWithThisPhoneNumber int
-	err := repository.connection.QueryRow(countQuery, phoneNumber).Scan(&amountOfUsersWithThisPhoneNumber)
+	err = getExecutor(ctx, repository.connection).QueryRowContext(ctx, countQuery, phoneNumber).Scan(&amountOfUsersWithThisPhoneNumber)
 	if err != nil {
 		return 0, fmt.Errorf("while counting amount of users with phone number %s happened error: %w", phoneNumber, err)
 	}
 
 	return amountOfUsersWithThisPhoneNumber, nil
 }
+
+func (repository *PgUserRepository) UpdateProfile(ctx context.Context, user *entities.User) error {
+	user.UpdatedAt = time.Now()
+
+	updateQuery := "UPDATE users SET display_name = $2, updated_at = $3 WHERE id = $1 AND status <> $4"
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, updateQuery, user.Id, user.DisplayName, user.UpdatedAt, entities.UserStatusDeleted)
+	if err != nil {
+		return fmt.Errorf("while updating profile of user with id %s happened error: %w", user.Id, err)
+	}
+
+	return checkUserAffected(result, user.Id, "updating profile of")
+}
+
+func (repository *PgUserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, updatedAt time.Time) error {
+	updateQuery := "UPDATE users SET status = $2, updated_at = $3 WHERE id = $1 AND status <> $4"
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, updateQuery, id, status, updatedAt, entities.UserStatusDeleted)
+	if err != nil {
+		return fmt.Errorf("while updating status of user with id %s happened error: %w", id, err)
+	}
+
+	return checkUserAffected(result, id, "updating status of")
+}
+
+func (repository *PgUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string, updatedAt time.Time) error {
+	updateQuery := "UPDATE users SET user_role = $2, updated_at = $3 WHERE id = $1 AND status <> $4"
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, updateQuery, id, role, updatedAt, entities.UserStatusDeleted)
+	if err != nil {
+		return fmt.Errorf("while updating role of user with id %s happened error: %w", id, err)
+	}
+
+	return checkUserAffected(result, id, "updating role of")
+}
+
+func (repository *PgUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID, lastLoginAt time.Time) error {
+	_, err := getExecutor(ctx, repository.connection).ExecContext(ctx, "UPDATE users SET last_login_at = $2 WHERE id = $1", id, lastLoginAt)
+	if err != nil {
+		return fmt.Errorf("while updating last login of user with id %s happened error: %w", id, err)
+	}
+
+	return nil
+}
+
+func (repository *PgUserRepository) UpdatePhoneNumber(ctx context.Context, id uuid.UUID, phoneNumber string) error {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return fmt.Errorf("while updating phone number of user with id %s happened error: %w", id, err)
+	}
+
+	updateQuery := "UPDATE users SET phone_number = $2, updated_at = $3 WHERE id = $1"
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, updateQuery, id, phoneNumber, time.Now())
+	if isUniqueViolation(err) {
+		return ErrPhoneNumberTaken
+	}
+
+	if err != nil {
+		return fmt.Errorf("while updating phone number of user with id %s happened error: %w", id, err)
+	}
+
+	return checkUserAffected(result, id, "updating phone number of")
+}
+
+func (repository *PgUserRepository) MarkDeleted(ctx context.Context, id uuid.UUID, deletedAt time.Time, isRestorable bool) error {
+	markQuery := `UPDATE users SET status = $2, deleted_at = $3, updated_at = $3,
+			status_before_deletion = CASE WHEN $4 THEN status ELSE NULL END
+		WHERE id = $1 AND status <> $2`
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, markQuery, id, entities.UserStatusDeleted, deletedAt, isRestorable)
+	if err != nil {
+		return fmt.Errorf("while deleting user with id %s happened error: %w", id, err)
+	}
+
+	return checkUserAffected(result, id, "deleting")
+}
+
+func (repository *PgUserRepository) Restore(ctx context.Context, id uuid.UUID, restoredAt time.Time) error {
+	restoreQuery := `UPDATE users SET status = status_before_deletion, status_before_deletion = NULL, deleted_at = NULL, updated_at = $3
+		WHERE id = $1 AND status = $2 AND status_before_deletion IS NOT NULL`
+
+	result, err := getExecutor(ctx, repository.connection).ExecContext(ctx, restoreQuery, id, entities.UserStatusDeleted, restoredAt)
+	if err != nil {
+		return fmt.Errorf("while restoring user with id %s happened error: %w", id, err)
+	}
+
+	return checkUserAffected(result, id, "restoring")
+}
+
+func (repository *PgUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	purgeQuery := "DELETE FROM users WHERE status = $1 AND deleted_at <= $2 RETURNING id"
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, purgeQuery, entities.UserStatusDeleted, deletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("while purging deleted users happened error: %w", err)
+	}
+	defer rows.Close()
+
+	var purgedIds []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("while purging deleted users happened error: %w", err)
+		}
+
+		purgedIds = append(purgedIds, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("while purging deleted users happened error: %w", err)
+	}
+
+	return purgedIds, nil
+}
+
+func (repository *PgUserRepository) List(ctx context.Context, filter UserFilter) ([]*entities.User, int, error) {
+	var conditions []string
+	var arguments []any
+
+	addCondition := func(condition string, argument any) {
+		arguments = append(arguments, argument)
+		conditions = append(conditions, fmt.Sprintf(condition, len(arguments)))
+	}
+
+	if filter.Role != "" {
+		addCondition("(user_role = $%[1]d OR EXISTS (SELECT 1 FROM user_roles WHERE user_id = users.id AND role_name = $%[1]d))", filter.Role)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.PhoneNumberPrefix != "" {
+		// Prefix consists of "+" and digits only, so it doesn't contain wildcards of LIKE
+		addCondition("phone_number LIKE $%d", filter.PhoneNumberPrefix+"%")
+	}
+
+	whereClause := ""
+	if len(conditions) != 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := getExecutor(ctx, repository.connection).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+whereClause, arguments...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("while counting users happened error: %w", err)
+	}
+
+	listQuery := fmt.Sprintf("SELECT %s FROM users%s ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d",
+		userColumns, whereClause, len(arguments)+1, len(arguments)+2)
+
+	rows, err := getExecutor(ctx, repository.connection).QueryContext(ctx, listQuery, append(arguments, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("while listing users happened error: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*entities.User, 0, filter.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("while listing users happened error: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("while listing users happened error: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// Returns ErrUserNotFound if statement didn't affect user, action is used in message of error
+func checkUserAffected(result sql.Result, id uuid.UUID, action string) error {
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("while %s user with id %s happened error: %w", action, id, err)
+	}
+
+	if affectedRows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Common part of *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*entities.User, error) {
+	user := &entities.User{}
+	var lastLoginAt sql.NullTime
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&user.Id, &user.PhoneNumber, &user.UserRole, &user.DisplayName, &user.Status,
+		&user.CreatedAt, &user.UpdatedAt, &lastLoginAt, &deletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
+	return user, nil
+}
+
+// Implementation of UserRepository which keeps users in memory, with the same semantics as PgUserRepository
+// (including uniqueness of phone number)
+type InMemoryUserRepository struct {
+	database    *memoryDatabase
+	phoneParser phone.Parser
+}
+
+func NewInMemoryUserRepository(phoneParser phone.Parser) UserRepository {
+	return &InMemoryUserRepository{database: newMemoryDatabase(), phoneParser: phoneParser}
+}
+
+func (repository *InMemoryUserRepository) Add(ctx context.Context, user *entities.User) error {
+	phoneNumber, err := repository.phoneParser.Normalize(user.PhoneNumber)
+	if err != nil {
+		return fmt.Errorf("while adding new user happened error: %w", err)
+	}
+	user.PhoneNumber = phoneNumber
+
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	if repository.findByPhoneNumber(phoneNumber) != nil {
+		return ErrUserAlreadyExists
+	}
+
+	if _, exists := repository.database.users[user.Id]; exists {
+		return fmt.Errorf("while adding new user happened error: user with id %s already exists", user.Id)
+	}
+
+	now := time.Now()
+	if user.Status == "" {
+		user.Status = entities.UserStatusActive
+	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	repository.database.users[user.Id] = *copyUser(*user)
+	return nil
+}
+
+func (repository *InMemoryUserRepository) Get(ctx context.Context, phoneNumber string) (*entities.User, error) {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving user with phone number happened error: %w", err)
+	}
+
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	user := repository.findByPhoneNumber(phoneNumber)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return copyUser(*user), nil
+}
+
+func (repository *InMemoryUserRepository) GetById(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	user, exists := repository.database.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	return copyUser(user), nil
+}
+
+func (repository *InMemoryUserRepository) Count(ctx context.Context, phoneNumber string) (int, error) {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return 0, fmt.Errorf("while counting amount of users with phone number happened error: %w", err)
+	}
+
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	if repository.findByPhoneNumber(phoneNumber) == nil {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (repository *InMemoryUserRepository) UpdateProfile(ctx context.Context, user *entities.User) error {
+	user.UpdatedAt = time.Now()
+
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	storedUser, exists := repository.database.users[user.Id]
+	if !exists || storedUser.Status == entities.UserStatusDeleted {
+		return ErrUserNotFound
+	}
+
+	storedUser.DisplayName = user.DisplayName
+	storedUser.UpdatedAt = user.UpdatedAt
+
+	repository.database.users[user.Id] = storedUser
+	return nil
+}
+
+func (repository *InMemoryUserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, updatedAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	if !exists || user.Status == entities.UserStatusDeleted {
+		return ErrUserNotFound
+	}
+
+	user.Status = status
+	user.UpdatedAt = updatedAt
+
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string, updatedAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	if !exists || user.Status == entities.UserStatusDeleted {
+		return ErrUserNotFound
+	}
+
+	user.UserRole = role
+	user.UpdatedAt = updatedAt
+
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID, lastLoginAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	if !exists {
+		return nil
+	}
+
+	user.LastLoginAt = &lastLoginAt
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) UpdatePhoneNumber(ctx context.Context, id uuid.UUID, phoneNumber string) error {
+	phoneNumber, err := repository.phoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return fmt.Errorf("while updating phone number of user with id %s happened error: %w", id, err)
+	}
+
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	if owner := repository.findByPhoneNumber(phoneNumber); owner != nil && owner.Id != id {
+		return ErrPhoneNumberTaken
+	}
+
+	user.PhoneNumber = phoneNumber
+	user.UpdatedAt = time.Now()
+
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) MarkDeleted(ctx context.Context, id uuid.UUID, deletedAt time.Time, isRestorable bool) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	if !exists || user.Status == entities.UserStatusDeleted {
+		return ErrUserNotFound
+	}
+
+	if isRestorable {
+		repository.database.statusesBeforeDeletion[id] = user.Status
+	}
+
+	user.Status = entities.UserStatusDeleted
+	user.DeletedAt = &deletedAt
+	user.UpdatedAt = deletedAt
+
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) Restore(ctx context.Context, id uuid.UUID, restoredAt time.Time) error {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	user, exists := repository.database.users[id]
+	statusBeforeDeletion, isRestorable := repository.database.statusesBeforeDeletion[id]
+	if !exists || user.Status != entities.UserStatusDeleted || !isRestorable {
+		return ErrUserNotFound
+	}
+
+	delete(repository.database.statusesBeforeDeletion, id)
+
+	user.Status = statusBeforeDeletion
+	user.DeletedAt = nil
+	user.UpdatedAt = restoredAt
+
+	repository.database.users[id] = user
+	return nil
+}
+
+func (repository *InMemoryUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	repository.database.mutex.Lock()
+	defer repository.database.mutex.Unlock()
+
+	var purgedIds []uuid.UUID
+	for id, user := range repository.database.users {
+		if user.Status != entities.UserStatusDeleted || user.DeletedAt == nil || user.DeletedAt.After(deletedBefore) {
+			continue
+		}
+
+		delete(repository.database.users, id)
+		delete(repository.database.statusesBeforeDeletion, id)
+
+		// The same as ON DELETE CASCADE of tables referencing users
+		delete(repository.database.userRoles, id)
+		for tokenId, token := range repository.database.refreshTokens {
+			if token.UserId == id {
+				delete(repository.database.refreshTokens, tokenId)
+			}
+		}
+
+		purgedIds = append(purgedIds, id)
+	}
+
+	return purgedIds, nil
+}
+
+func (repository *InMemoryUserRepository) List(ctx context.Context, filter UserFilter) ([]*entities.User, int, error) {
+	repository.database.mutex.RLock()
+	defer repository.database.mutex.RUnlock()
+
+	matchingUsers := []entities.User{}
+	for _, user := range repository.database.users {
+		if filter.Role != "" && user.UserRole != filter.Role && !slices.Contains(repository.database.userRoles[user.Id], filter.Role) {
+			continue
+		}
+
+		if filter.Status != "" && user.Status != filter.Status {
+			continue
+		}
+
+		if filter.PhoneNumberPrefix != "" && !strings.HasPrefix(user.PhoneNumber, filter.PhoneNumberPrefix) {
+			continue
+		}
+
+		matchingUsers = append(matchingUsers, user)
+	}
+
+	// Newest first, the same as ORDER BY created_at DESC, id
+	slices.SortFunc(matchingUsers, func(first entities.User, second entities.User) int {
+		if comparison := second.CreatedAt.Compare(first.CreatedAt); comparison != 0 {
+			return comparison
+		}
+
+		return bytes.Compare(first.Id[:], second.Id[:])
+	})
+
+	start := min(max(filter.Offset, 0), len(matchingUsers))
+	end := min(start+max(filter.Limit, 0), len(matchingUsers))
+
+	users := make([]*entities.User, 0, end-start)
+	for _, user := range matchingUsers[start:end] {
+		users = append(users, copyUser(user))
+	}
+
+	return users, len(matchingUsers), nil
+}
+
+// Must be called under lock of database
+func (repository *InMemoryUserRepository) findByPhoneNumber(phoneNumber string) *entities.User {
+	for _, user := range repository.database.users {
+		if user.PhoneNumber == phoneNumber {
+			return &user
+		}
+	}
+
+	return nil
+}
+
+// Returned user doesn't share optional fields with stored one, so caller can change it freely
+func copyUser(user entities.User) *entities.User {
+	if user.LastLoginAt != nil {
+		lastLoginAt := *user.LastLoginAt
+		user.LastLoginAt = &lastLoginAt
+	}
+
+	if user.DeletedAt != nil {
+		deletedAt := *user.DeletedAt
+		user.DeletedAt = &deletedAt
+	}
+
+	return &user
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const claimsContextKey = "token_claims"
+
+// Lets request through only with valid access token in "Authorization: Bearer <token>" header.
+// Claims of token are available in handler through GetClaims
+func RequireAuth(tokenHandler services.TokenHandler, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			token, exists := ExtractBearerToken(context)
+			if !exists {
+				return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Authorization header with bearer token is required"})
+			}
+
+			claims, err := tokenHandler.ParseToken(context.Request().Context(), token)
+			if err != nil {
+				if errors.Is(err, services.ErrTokenRevoked) {
+					return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Token is revoked"})
+				}
+
+				if errors.Is(err, services.ErrUserNotActive) {
+					return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Account is blocked or deleted", ErrorCode: dtos.ErrorCodeAccountNotActive})
+				}
+
+				logger.Debug(fmt.Errorf("request with invalid token: %w", err).Error())
+				return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Invalid token"})
+			}
+
+			context.Set(claimsContextKey, claims)
+			return next(context)
+		}
+	}
+}
+
+// Lets request through only if user has one of passed roles (as primary or additional role). Must be used after RequireAuth.
+// Roles are taken from token, so after change of roles sessions of user have to be revoked
+func RequireRole(logger *zap.Logger, roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			claims := GetClaims(context)
+			if claims == nil {
+				return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Authorization header with bearer token is required"})
+			}
+
+			hasRole := slices.Contains(roles, claims.UserRole) || slices.ContainsFunc(claims.Roles, func(role string) bool {
+				return slices.Contains(roles, role)
+			})
+
+			if !hasRole {
+				logger.Warn(fmt.Sprintf("user %s with role %s tried to access %s", claims.UserId, claims.UserRole, context.Path()))
+				return context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Not enough rights", ErrorCode: dtos.ErrorCodeForbidden})
+			}
+
+			return next(context)
+		}
+	}
+}
+
+// Returns claims of access token checked by RequireAuth, nil if request wasn't authenticated
+func GetClaims(context echo.Context) *services.TokenClaims {
+	claims, _ := context.Get(claimsContextKey).(*services.TokenClaims)
+	return claims
+}
+
+// Returns token from "Authorization: Bearer <token>" header
+func ExtractBearerToken(context echo.Context) (string, bool) {
+	authorizationHeader := context.Request().Header.Get(echo.HeaderAuthorization)
+
+	token, hasPrefix := strings.CutPrefix(authorizationHeader, "Bearer ")
+	if !hasPrefix || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
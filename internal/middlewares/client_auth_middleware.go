@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Lets request through only with id and secret of one of clients in "Authorization: Basic" header
+// (client_secret_basic of RFC 6749). Used for endpoints called by other services, not by users
+func RequireClientCredentials(clientSecrets map[string]string, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			clientId, clientSecret, exists := context.Request().BasicAuth()
+			expectedSecret, isKnown := clientSecrets[clientId]
+
+			if !exists || !isKnown || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(expectedSecret)) != 1 {
+				logger.Warn(fmt.Sprintf("request of unauthenticated client %q to %s", clientId, context.Path()))
+
+				context.Response().Header().Set("WWW-Authenticate", `Basic realm="auth-service"`)
+				return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Client credentials are missing or invalid", ErrorCode: dtos.ErrorCodeInvalidClient})
+			}
+
+			return next(context)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Limits requests from one client ip to handler (sliding window). Name separates counters of different handlers
+func RateLimitByIp(limiter services.RateLimiter, name string, limit int, window time.Duration, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			result, err := limiter.Allow(services.RateLimitRule{Key: name + ":ip:" + context.RealIP(), Limit: limit, Window: window})
+			if err != nil {
+				logger.Error(fmt.Errorf("while checking rate limit happened error: %w", err).Error())
+				return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+			}
+
+			if !result.IsAllowed {
+				return RespondTooManyRequests(context, result.RetryAfter, "Too many requests, try again later", dtos.ErrorCodeRateLimited)
+			}
+
+			return next(context)
+		}
+	}
+}
+
+// Responds with 429 and Retry-After header (in whole seconds, rounded up)
+func RespondTooManyRequests(context echo.Context, retryAfter time.Duration, errorMessage string, errorCode string) error {
+	retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+	context.Response().Header().Set("Retry-After", strconv.Itoa(max(retryAfterSeconds, 1)))
+
+	return context.JSON(http.StatusTooManyRequests, dtos.ErrorDto{ErrorMessage: errorMessage, ErrorCode: errorCode})
+}
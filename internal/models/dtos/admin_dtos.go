@@ -0,0 +1,43 @@
+package dtos
+
+import "time"
+
+type AdminUserResponse struct {
+	Id          string     `json:"id"`
+	PhoneNumber string     `json:"phone_number"`
+	Role        string     `json:"role"`
+	DisplayName string     `json:"display_name"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+type ListUsersRequest struct {
+	// Starts from 1
+	Page     int `query:"page"`
+	PageSize int `query:"page_size"`
+
+	Role   string `query:"role"`
+	Status string `query:"status"`
+
+	// Beginning of phone number, e.g. "+7912"
+	PhonePrefix string `query:"phone_prefix"`
+}
+
+type UsersPageResponse struct {
+	Items    []AdminUserResponse `json:"items"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type RotateKeyResponse struct {
+	// Id of new signing key ("kid" header of new tokens)
+	KeyId string `json:"kid"`
+}
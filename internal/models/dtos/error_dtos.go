@@ -2,4 +2,37 @@ package dtos
 
 type ErrorDto struct {
 	ErrorMessage string `json:"error_message"`
+
+	// Machine-readable reason of error, set for errors client has to handle specifically
+	ErrorCode string `json:"error_code,omitempty"`
 }
+
+const (
+	ErrorCodeSmsCodeNotRequested    = "sms_code_not_requested"
+	ErrorCodeSmsCodeInvalid         = "sms_code_invalid"
+	ErrorCodeSmsCodeLocked          = "sms_code_locked"
+	ErrorCodeSmsResendCooldown      = "sms_resend_cooldown"
+	ErrorCodeRateLimited            = "rate_limited"
+	ErrorCodeInvalidPhoneNumber     = "invalid_phone_number"
+	ErrorCodePhoneCountryNotAllowed = "phone_country_not_allowed"
+	ErrorCodeUserNotRegistered      = "user_not_registered"
+	ErrorCodeRoleRequired           = "role_required"
+	ErrorCodePhoneNumberTaken       = "phone_number_taken"
+	ErrorCodePhoneNumberUnchanged   = "phone_number_unchanged"
+	ErrorCodeAccountDeleted         = "account_deleted"
+	ErrorCodeAccountSuspended       = "account_suspended"
+	ErrorCodeAccountBanned          = "account_banned"
+	ErrorCodeAccountNotActive       = "account_not_active"
+	ErrorCodeForbidden              = "forbidden"
+	ErrorCodeCannotModifyOwnAccount = "cannot_modify_own_account"
+	ErrorCodeKeyRotationDisabled    = "key_rotation_disabled"
+	ErrorCodeRoleExists             = "role_exists"
+	ErrorCodeRoleInUse              = "role_in_use"
+	ErrorCodeRoleBuiltIn            = "role_built_in"
+	ErrorCodeRoleNotFound           = "role_not_found"
+	ErrorCodeInviteCodeRequired     = "invite_code_required"
+	ErrorCodeInviteCodeInvalid      = "invite_code_invalid"
+	ErrorCodeAccountPendingApproval = "account_pending_approval"
+	ErrorCodeUserAlreadyExists      = "user_already_exists"
+	ErrorCodeInvalidClient          = "invalid_client"
+)
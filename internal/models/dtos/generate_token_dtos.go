@@ -2,7 +2,6 @@ package dtos
 
 type GenerateTokenRequest struct {
 	UserId string `json:"user_id"`
-	Role   string `json:"role"`
 }
 
 type TokenResponse struct {
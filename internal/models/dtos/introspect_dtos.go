@@ -0,0 +1,29 @@
+package dtos
+
+type IntrospectTokenRequest struct {
+	Token string `json:"token" form:"token"`
+
+	// Only "access_token" is supported, other tokens are reported as inactive
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint"`
+}
+
+// Response of token introspection (RFC 7662). For inactive token only "active" field is set.
+// Optional "client_id" is not returned: tokens are issued to users and don't record any client
+type IntrospectTokenResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+	Role      string   `json:"role,omitempty"`
+
+	// All roles of user, primary role (role) is first
+	Roles []string `json:"roles,omitempty"`
+
+	// Same as scope, but as array
+	Permissions []string `json:"permissions,omitempty"`
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import "time"
+
+type InviteCodeDto struct {
+	Id        string     `json:"id"`
+	Role      string     `json:"role"`
+	MaxUses   int        `json:"max_uses"`
+	UsedCount int        `json:"used_count"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type CreateInviteCodeRequest struct {
+	Role string `json:"role"`
+
+	// 1 by default
+	MaxUses int `json:"max_uses"`
+
+	// 168 (week) by default, 0 - code doesn't expire
+	ExpiresInHours *int `json:"expires_in_hours"`
+}
+
+type CreateInviteCodeResponse struct {
+	// Plain code, it's shown only once
+	Code string `json:"code"`
+
+	InviteCode InviteCodeDto `json:"invite_code"`
+}
@@ -0,0 +1,27 @@
+package dtos
+
+type LoginStartRequest struct {
+	PhoneNumber string `json:"phone_number"`
+}
+
+type LoginCompleteRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	SmsCode     string `json:"sms_code"`
+
+	// Required only for new user, ignored for registered one
+	Role string `json:"role"`
+
+	// Used only for new user, same as in register request
+	InviteCode string `json:"invite_code"`
+
+	// Restores account which user deleted, if grace period is not over yet. Without it login of deleted account is rejected
+	RestoreAccount bool `json:"restore_account"`
+}
+
+type LoginCompleteResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+
+	// True if user was registered by this login
+	IsNewUser bool `json:"is_new_user"`
+}
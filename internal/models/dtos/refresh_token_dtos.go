@@ -0,0 +1,10 @@
+package dtos
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenPairResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -3,4 +3,7 @@ package dtos
 type RegisterRequest struct {
 	PhoneNumber string `json:"phone_number"`
 	Role        string `json:"role"`
+
+	// Required for roles with invite_code registration policy, lets skip approval for roles with approval policy
+	InviteCode string `json:"invite_code"`
 }
@@ -0,0 +1,13 @@
+package dtos
+
+type LogoutRequest struct {
+	// Optional: if passed, session of this refresh token is closed too
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeTokenRequest struct {
+	Token string `json:"token" form:"token"`
+
+	// "access_token" or "refresh_token", if empty - both are tried
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint"`
+}
@@ -0,0 +1,44 @@
+package dtos
+
+import "time"
+
+type RoleDto struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Permissions      []string `json:"permissions"`
+	IsSelfAssignable bool     `json:"is_self_assignable"`
+
+	// open, invite_code or approval
+	RegistrationPolicy string    `json:"registration_policy"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Permissions      []string `json:"permissions"`
+	IsSelfAssignable bool     `json:"is_self_assignable"`
+
+	// open (by default), invite_code or approval
+	RegistrationPolicy string `json:"registration_policy"`
+}
+
+// Role is replaced as whole, name of role can't be changed
+type UpdateRoleRequest struct {
+	Description      string   `json:"description"`
+	Permissions      []string `json:"permissions"`
+	IsSelfAssignable bool     `json:"is_self_assignable"`
+
+	// open (by default), invite_code or approval
+	RegistrationPolicy string `json:"registration_policy"`
+}
+
+type UserRolesResponse struct {
+	PrimaryRole     string   `json:"primary_role"`
+	AdditionalRoles []string `json:"additional_roles"`
+}
+
+type SetUserRolesRequest struct {
+	AdditionalRoles []string `json:"additional_roles"`
+}
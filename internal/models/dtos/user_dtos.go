@@ -0,0 +1,57 @@
+package dtos
+
+import "time"
+
+type UserProfileResponse struct {
+	Id          string     `json:"id"`
+	PhoneNumber string     `json:"phone_number"`
+	Role        string     `json:"role"`
+	DisplayName string     `json:"display_name"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// Only passed fields are changed
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+}
+
+type StartPhoneChangeRequest struct {
+	NewPhoneNumber string `json:"new_phone_number"`
+}
+
+type CompletePhoneChangeRequest struct {
+	NewPhoneNumber string `json:"new_phone_number"`
+
+	// Code sent to new phone number
+	SmsCode string `json:"sms_code"`
+
+	// Code sent to current phone number of user
+	CurrentSmsCode string `json:"current_sms_code"`
+}
+
+type DeleteAccountResponse struct {
+	// Until this moment data of user is kept, then it's purged
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type UserDataExportResponse struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	Profile    UserProfileResponse `json:"profile"`
+
+	// Roles given to user besides primary one (primary role is in profile)
+	AdditionalRoles []string `json:"additional_roles"`
+
+	// Refresh tokens issued to user (tokens themselves are not stored, only their metadata)
+	Sessions []SessionExportDto `json:"sessions"`
+}
+
+type SessionExportDto struct {
+	Id        string     `json:"id"`
+	FamilyId  string     `json:"family_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
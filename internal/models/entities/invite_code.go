@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Code which lets user register with role requiring invite or approval
+type InviteCode struct {
+	Id uuid.UUID
+
+	// SHA-256 of code, plain code is shown only to admin who created it
+	CodeHash string
+
+	RoleName string
+
+	MaxUses   int
+	UsedCount int
+
+	// Admin who created code
+	CreatedBy uuid.UUID
+
+	CreatedAt time.Time
+
+	// nil if code doesn't expire
+	ExpiresAt *time.Time
+
+	// nil while code is not revoked
+	RevokedAt *time.Time
+}
@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+// Message to kafka saved in the same transaction as change it's about and published later by relay
+type OutboxMessage struct {
+	// Grows with every message, so messages are published in order they were written
+	Id int64
+
+	Topic string
+
+	// Key of kafka message, usually id of aggregate (e.g. user). Messages with the same topic and key are published in order
+	Key string
+
+	Payload []byte
+
+	CreatedAt time.Time
+
+	// Amount of failed attempts to publish message
+	Attempts  int
+	LastError string
+
+	// Message is not published before this moment, it's moved forward after every failed attempt
+	NextAttemptAt time.Time
+
+	// nil while message is not published
+	DeliveredAt *time.Time
+
+	// Set when message failed max amount of attempts, then it's not published anymore
+	FailedAt *time.Time
+}
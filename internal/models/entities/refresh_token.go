@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	Id uuid.UUID
+
+	UserId uuid.UUID
+
+	// All tokens produced by rotation of one login share the same family
+	FamilyId uuid.UUID
+
+	// SHA-256 of token, plain token is never stored
+	TokenHash string
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	// nil while token is not used or revoked
+	RevokedAt *time.Time
+
+	// Id of token that replaced this one while rotation
+	ReplacedBy *uuid.UUID
+}
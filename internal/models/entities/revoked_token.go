@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+type RevokedToken struct {
+	// "jti" claim of revoked token
+	TokenId string
+
+	RevokedAt time.Time
+
+	// Expiration of token itself, after it record is useless and can be pruned
+	ExpiresAt time.Time
+}
@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+// How user can get role at registration (only for self-assignable roles)
+const (
+	RoleRegistrationOpen = "open"
+
+	// Valid invite code is required
+	RoleRegistrationInviteCode = "invite_code"
+
+	// User without invite code starts in pending_approval status
+	RoleRegistrationApproval = "approval"
+)
+
+type Role struct {
+	Name        string
+	Description string
+
+	// Permissions are embedded into tokens of users with this role
+	Permissions []string
+
+	// Role can be chosen by user at registration
+	IsSelfAssignable bool
+
+	// One of RoleRegistration* constants
+	RegistrationPolicy string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
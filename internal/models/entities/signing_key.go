@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+type SigningKey struct {
+	// Value of "kid" header of tokens signed by this key
+	Id string
+
+	Algorithm string
+
+	// Raw secret for HS256, PKCS #8 DER for asymmetric algorithms
+	PrivateKey []byte
+
+	CreatedAt time.Time
+
+	// Key is retired when newer key replaced it, it's still used for verification until ExpiresAt
+	RetiredAt *time.Time
+	ExpiresAt *time.Time
+}
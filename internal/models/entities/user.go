@@ -1,9 +1,40 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Other roles are defined in database, this one is required for admin API
+const UserRoleAdmin = "Admin"
+
+const (
+	UserStatusActive = "active"
+
+	// Registered with role which requires approval, user can't log in until admin approves it
+	UserStatusPendingApproval = "pending_approval"
+
+	// Temporarily blocked by admin, user can't log in until unsuspended
+	UserStatusSuspended = "suspended"
+
+	// Blocked by admin for abuse, user can't log in until unbanned
+	UserStatusBanned = "banned"
+
+	// Deletion was requested, user is purged after grace period
+	UserStatusDeleted = "deleted"
+)
 
 type User struct {
 	Id          uuid.UUID
 	PhoneNumber string
 	UserRole    string
+
+	DisplayName string
+	Status      string
+
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	LastLoginAt *time.Time
+	DeletedAt   *time.Time
 }
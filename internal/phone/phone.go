@@ -0,0 +1,179 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidPhoneNumber = errors.New("phone number is invalid")
+var ErrCountryNotAllowed = errors.New("phone numbers of this country are not allowed")
+
+type Parser interface {
+	// Parses phone number in international ("+7 912 345-67-89", "007...") or national ("8 (912) 345-67-89") format
+	// and returns it in E.164 format ("+79123456789")
+	Normalize(phoneNumber string) (string, error)
+}
+
+type country struct {
+	// Lengths of national significant number (without country code and trunk prefix)
+	numberLengths []int
+
+	// Prefix of numbers in national format, "8" in Russia
+	trunkPrefix string
+}
+
+// Countries with known numbering plan, numbers of other countries are checked only by E.164 length
+var countries = map[string]country{
+	"1":   {numberLengths: []int{10}, trunkPrefix: "1"},
+	"7":   {numberLengths: []int{10}, trunkPrefix: "8"},
+	"44":  {numberLengths: []int{10}, trunkPrefix: "0"},
+	"49":  {numberLengths: []int{10, 11}, trunkPrefix: "0"},
+	"374": {numberLengths: []int{8}, trunkPrefix: "0"},
+	"375": {numberLengths: []int{9}, trunkPrefix: "80"},
+	"380": {numberLengths: []int{9}, trunkPrefix: "0"},
+	"992": {numberLengths: []int{9}},
+	"994": {numberLengths: []int{9}, trunkPrefix: "0"},
+	"995": {numberLengths: []int{9}, trunkPrefix: "0"},
+	"996": {numberLengths: []int{9}, trunkPrefix: "0"},
+	"998": {numberLengths: []int{9}},
+}
+
+// E.164 limits number to 15 digits with country code
+const maxE164Digits = 15
+const minE164Digits = 8
+
+type E164Parser struct {
+	allowedCountryCodes []string
+
+	// Country which numbers in national format belong to
+	defaultCountryCode string
+}
+
+func NewParser(allowedCountryCodes []string, defaultCountryCode string) (Parser, error) {
+	if len(allowedCountryCodes) == 0 {
+		return nil, errors.New("at least one allowed country code is required")
+	}
+
+	allowedCountryCodes = slices.Clone(allowedCountryCodes)
+	for i, countryCode := range allowedCountryCodes {
+		countryCode = strings.TrimPrefix(countryCode, "+")
+		if !isDigits(countryCode) || len(countryCode) > 3 {
+			return nil, fmt.Errorf("invalid country code: %s", allowedCountryCodes[i])
+		}
+
+		allowedCountryCodes[i] = countryCode
+	}
+
+	defaultCountryCode = strings.TrimPrefix(defaultCountryCode, "+")
+	if defaultCountryCode != "" && !slices.Contains(allowedCountryCodes, defaultCountryCode) {
+		return nil, fmt.Errorf("default country code %s is not allowed", defaultCountryCode)
+	}
+
+	return &E164Parser{allowedCountryCodes: allowedCountryCodes, defaultCountryCode: defaultCountryCode}, nil
+}
+
+func (parser *E164Parser) Normalize(phoneNumber string) (string, error) {
+	digits, isInternational, err := stripFormatting(phoneNumber)
+	if err != nil {
+		return "", err
+	}
+
+	if !isInternational {
+		digits, err = parser.nationalToInternational(digits)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits {
+		return "", fmt.Errorf("%w: wrong amount of digits", ErrInvalidPhoneNumber)
+	}
+
+	countryCode, nationalNumber, isAllowed := parser.splitCountryCode(digits)
+	if !isAllowed {
+		return "", ErrCountryNotAllowed
+	}
+
+	if country, isKnown := countries[countryCode]; isKnown && !slices.Contains(country.numberLengths, len(nationalNumber)) {
+		return "", fmt.Errorf("%w: wrong amount of digits for country code +%s", ErrInvalidPhoneNumber, countryCode)
+	}
+
+	return "+" + digits, nil
+}
+
+// Removes spaces, dashes, dots and parentheses. Returns digits and whether number had international prefix ("+" or "00")
+func stripFormatting(phoneNumber string) (string, bool, error) {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+
+	isInternational := strings.HasPrefix(phoneNumber, "+")
+	phoneNumber = strings.TrimPrefix(phoneNumber, "+")
+
+	var digits strings.Builder
+	for _, symbol := range phoneNumber {
+		switch {
+		case symbol >= '0' && symbol <= '9':
+			digits.WriteRune(symbol)
+		case symbol == ' ' || symbol == '-' || symbol == '.' || symbol == '(' || symbol == ')':
+			continue
+		default:
+			return "", false, fmt.Errorf("%w: unexpected symbol %q", ErrInvalidPhoneNumber, symbol)
+		}
+	}
+
+	result := digits.String()
+	if !isInternational && strings.HasPrefix(result, "00") {
+		return result[2:], true, nil
+	}
+
+	return result, isInternational, nil
+}
+
+func (parser *E164Parser) nationalToInternational(digits string) (string, error) {
+	if parser.defaultCountryCode == "" {
+		return "", fmt.Errorf("%w: number must start with country code", ErrInvalidPhoneNumber)
+	}
+
+	// Russian numbers are commonly written both as "8 912 ..." and "7 912 ..." without "+"
+	if strings.HasPrefix(digits, parser.defaultCountryCode) && parser.hasValidNationalLength(digits[len(parser.defaultCountryCode):]) {
+		return digits, nil
+	}
+
+	trunkPrefix := countries[parser.defaultCountryCode].trunkPrefix
+	if trunkPrefix != "" && strings.HasPrefix(digits, trunkPrefix) {
+		digits = digits[len(trunkPrefix):]
+	}
+
+	return parser.defaultCountryCode + digits, nil
+}
+
+func (parser *E164Parser) hasValidNationalLength(nationalNumber string) bool {
+	country, isKnown := countries[parser.defaultCountryCode]
+	return isKnown && slices.Contains(country.numberLengths, len(nationalNumber))
+}
+
+// Country codes are prefix-free, so at most one allowed code matches
+func (parser *E164Parser) splitCountryCode(digits string) (string, string, bool) {
+	for _, countryCode := range parser.allowedCountryCodes {
+		if strings.HasPrefix(digits, countryCode) {
+			return countryCode, digits[len(countryCode):], true
+		}
+	}
+
+	return "", "", false
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, symbol := range value {
+		if symbol < '0' || symbol > '9' {
+			return false
+		}
+	}
+
+	return true
+}
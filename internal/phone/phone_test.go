@@ -0,0 +1,124 @@
+package phone_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WebChads/AuthService/internal/phone"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name                string
+		allowedCountryCodes []string
+		defaultCountryCode  string
+		phoneNumber         string
+		expected            string
+		expectedErr         error
+	}{
+		{name: "E.164 number", phoneNumber: "+79123456789", expected: "+79123456789"},
+		{name: "plus, spaces and dashes", phoneNumber: " +7 912 345-67-89 ", expected: "+79123456789"},
+		{name: "dots and parentheses", phoneNumber: "+7 (912) 345.67.89", expected: "+79123456789"},
+		{name: "international prefix 00", phoneNumber: "007 912 345 67 89", expected: "+79123456789"},
+		{name: "trunk prefix 8", phoneNumber: "8 (912) 345-67-89", expected: "+79123456789"},
+		{name: "country code without plus", phoneNumber: "7 912 345 67 89", expected: "+79123456789"},
+		{name: "default country for national number", phoneNumber: "912 345-67-89", expected: "+79123456789"},
+		{name: "other allowed country", phoneNumber: "+375 29 123-45-67", expected: "+375291234567"},
+		{
+			name:                "trunk prefix of default country 80",
+			allowedCountryCodes: []string{"375"},
+			defaultCountryCode:  "375",
+			phoneNumber:         "80 29 123-45-67",
+			expected:            "+375291234567",
+		},
+		{
+			name:                "unknown country checked only by E.164 length",
+			allowedCountryCodes: []string{"7", "86"},
+			defaultCountryCode:  "7",
+			phoneNumber:         "+86 138 0013 8000",
+			expected:            "+8613800138000",
+		},
+		{name: "disallowed country", phoneNumber: "+44 20 7946 0958", expectedErr: phone.ErrCountryNotAllowed},
+		{name: "disallowed country with 00", phoneNumber: "0044 20 7946 0958", expectedErr: phone.ErrCountryNotAllowed},
+		{
+			name:                "national number without default country",
+			allowedCountryCodes: []string{"7"},
+			phoneNumber:         "8 912 345-67-89",
+			expectedErr:         phone.ErrInvalidPhoneNumber,
+		},
+		{
+			name:                "international number without default country",
+			allowedCountryCodes: []string{"7"},
+			phoneNumber:         "+7 912 345-67-89",
+			expected:            "+79123456789",
+		},
+		{name: "too short for country", phoneNumber: "+7 912 345-67", expectedErr: phone.ErrInvalidPhoneNumber},
+		{name: "too long for country", phoneNumber: "8 912 345-67-89-0", expectedErr: phone.ErrInvalidPhoneNumber},
+		{name: "longer than E.164", phoneNumber: "+7 912 345 67 89 123 45", expectedErr: phone.ErrInvalidPhoneNumber},
+		{name: "letters", phoneNumber: "+7 912 CALL-ME", expectedErr: phone.ErrInvalidPhoneNumber},
+		{name: "plus in the middle", phoneNumber: "8+9123456789", expectedErr: phone.ErrInvalidPhoneNumber},
+		{name: "empty", phoneNumber: "", expectedErr: phone.ErrInvalidPhoneNumber},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowedCountryCodes := testCase.allowedCountryCodes
+			defaultCountryCode := testCase.defaultCountryCode
+			if allowedCountryCodes == nil {
+				allowedCountryCodes = []string{"7", "375"}
+				defaultCountryCode = "7"
+			}
+
+			parser, err := phone.NewParser(allowedCountryCodes, defaultCountryCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			normalized, err := parser.Normalize(testCase.phoneNumber)
+			if testCase.expectedErr != nil {
+				if !errors.Is(err, testCase.expectedErr) {
+					t.Fatalf("expected error %v for %q, got %q and %v", testCase.expectedErr, testCase.phoneNumber, normalized, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", testCase.phoneNumber, err)
+			}
+
+			if normalized != testCase.expected {
+				t.Fatalf("expected %q for %q, got %q", testCase.expected, testCase.phoneNumber, normalized)
+			}
+		})
+	}
+}
+
+func TestNewParserValidatesCountryCodes(t *testing.T) {
+	testCases := []struct {
+		name                string
+		allowedCountryCodes []string
+		defaultCountryCode  string
+		isValid             bool
+	}{
+		{name: "codes with and without plus", allowedCountryCodes: []string{"+7", "375"}, defaultCountryCode: "+7", isValid: true},
+		{name: "no default country", allowedCountryCodes: []string{"7"}, isValid: true},
+		{name: "no allowed countries", allowedCountryCodes: []string{}, defaultCountryCode: "7"},
+		{name: "default country is not allowed", allowedCountryCodes: []string{"375"}, defaultCountryCode: "7"},
+		{name: "code is not a number", allowedCountryCodes: []string{"ru"}},
+		{name: "code is too long", allowedCountryCodes: []string{"7912"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := phone.NewParser(testCase.allowedCountryCodes, testCase.defaultCountryCode)
+			if testCase.isValid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !testCase.isValid && err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
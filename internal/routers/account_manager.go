@@ -0,0 +1,134 @@
+package routers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Actions on account shared by user's own endpoints and admin API
+type AccountManager struct {
+	Logger              *zap.Logger
+	UserRepository      repositories.UserRepository
+	TokenHandler        services.TokenHandler
+	RefreshTokenHandler services.RefreshTokenHandler
+	KafkaProducer       services.KafkaProducer
+	UserStatusChecker   services.UserStatusChecker
+	UsersConfig         services.UsersConfig
+	TransactionRunner   repositories.TransactionRunner
+}
+
+func NewAccountManager(logger *zap.Logger,
+	userRepository repositories.UserRepository,
+	tokenHandler services.TokenHandler,
+	refreshTokenHandler services.RefreshTokenHandler,
+	kafkaProducer services.KafkaProducer,
+	userStatusChecker services.UserStatusChecker,
+	usersConfig services.UsersConfig,
+	transactionRunner repositories.TransactionRunner) *AccountManager {
+
+	return &AccountManager{
+		Logger:              logger,
+		UserRepository:      userRepository,
+		TokenHandler:        tokenHandler,
+		RefreshTokenHandler: refreshTokenHandler,
+		KafkaProducer:       kafkaProducer,
+		UserStatusChecker:   userStatusChecker,
+		UsersConfig:         usersConfig,
+		TransactionRunner:   transactionRunner,
+	}
+}
+
+// Revokes refresh tokens and access tokens of all sessions of user.
+// Also drops cached status of user, because sessions are usually revoked after its change
+func (accountManager *AccountManager) RevokeAllSessions(ctx context.Context, userId uuid.UUID) error {
+	accountManager.UserStatusChecker.Forget(userId)
+
+	err := accountManager.RefreshTokenHandler.RevokeAll(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return accountManager.TokenHandler.RevokeUserTokens(ctx, userId)
+}
+
+// Saves primary role of user together with event about it, so event is sent if and only if role is changed.
+// Returns repositories.ErrUserNotFound if user does not exists or deleted
+func (accountManager *AccountManager) ChangeRole(ctx context.Context, userId uuid.UUID, role string, updatedAt time.Time, event services.UserEvent) error {
+	return accountManager.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := accountManager.UserRepository.UpdateRole(ctx, userId, role, updatedAt)
+		if err != nil {
+			return err
+		}
+
+		return accountManager.KafkaProducer.PublishUserEvent(ctx, event)
+	})
+}
+
+// Marks user deleted, closes its sessions and notifies other services.
+// Restorable deletion (made by user) can be undone by user till purge, deletion made by admin is final.
+// Event about deletion is saved in the same transaction as user, so it's sent if and only if user is deleted.
+// Returns moment when data of user will be purged and repositories.ErrUserNotFound if user does not exists or already deleted
+func (accountManager *AccountManager) DeleteAccount(ctx context.Context, userId uuid.UUID, isRestorable bool) (time.Time, error) {
+	deletedAt := time.Now()
+	purgeAt := deletedAt.Add(accountManager.UsersConfig.DeletionGracePeriod())
+
+	err := accountManager.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := accountManager.UserRepository.MarkDeleted(ctx, userId, deletedAt, isRestorable)
+		if err != nil {
+			return err
+		}
+
+		return accountManager.KafkaProducer.PublishUserEvent(ctx,
+			services.NewUserEvent(services.UserEventDeleted, userId, deletedAt, services.UserDeletedEventData{PurgeAt: purgeAt}))
+	})
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = accountManager.RevokeAllSessions(ctx, userId)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("while revoking sessions of deleted user %s happened error: %w", userId, err)
+	}
+
+	return purgeAt, nil
+}
+
+// Cancels deletion made by user, user gets back status it had before deletion. Event about restoring is saved
+// in the same transaction as user. Returns restored user and repositories.ErrUserNotFound if user does not exists,
+// isn't deleted or was deleted by admin
+func (accountManager *AccountManager) RestoreAccount(ctx context.Context, userId uuid.UUID) (*entities.User, error) {
+	restoredAt := time.Now()
+	var restoredUser *entities.User
+
+	err := accountManager.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := accountManager.UserRepository.Restore(ctx, userId, restoredAt)
+		if err != nil {
+			return err
+		}
+
+		restoredUser, err = accountManager.UserRepository.GetById(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		return accountManager.KafkaProducer.PublishUserEvent(ctx,
+			services.NewUserEvent(services.UserEventRestored, userId, restoredAt, services.UserRestoredEventData{Status: restoredUser.Status}))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Status "deleted" could be cached while user was deleted
+	accountManager.UserStatusChecker.Forget(userId)
+
+	return restoredUser, nil
+}
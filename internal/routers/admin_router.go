@@ -0,0 +1,617 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/middlewares"
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AdminRouter struct {
+	Logger         *zap.Logger
+	UserRepository repositories.UserRepository
+	AccountManager *AccountManager
+	RoleService    services.RoleService
+	Keyring        services.Keyring
+}
+
+func NewAdminRouter(logger *zap.Logger,
+	userRepository repositories.UserRepository,
+	accountManager *AccountManager,
+	roleService services.RoleService,
+	keyring services.Keyring) *AdminRouter {
+
+	return &AdminRouter{
+		Logger:         logger,
+		UserRepository: userRepository,
+		AccountManager: accountManager,
+		RoleService:    roleService,
+		Keyring:        keyring,
+	}
+}
+
+var userStatuses = []string{entities.UserStatusActive, entities.UserStatusPendingApproval, entities.UserStatusSuspended, entities.UserStatusBanned, entities.UserStatusDeleted}
+
+var phonePrefixRegex = regexp.MustCompile(`^\+?\d{1,15}$`)
+
+const (
+	defaultUsersPageSize = 20
+	maxUsersPageSize     = 100
+)
+
+// ListUsers godoc
+// @Title ListUsers
+// @Summary Page of users with optional filtering
+// @Description Users are sorted from newest. Deleted users are listed until they are purged
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param page query int false "Number of page, starts from 1"
+// @Param page_size query int false "Size of page, from 1 to 100 (20 by default)"
+// @Param role query string false "Primary or additional role of users"
+// @Param status query string false "Status of users (active, suspended, banned, deleted)"
+// @Param phone_prefix query string false "Beginning of phone number in international format, e.g. +7912"
+// @Success 200 {object} dtos.UsersPageResponse "Page of users"
+// @Failure 400 {object} dtos.ErrorDto "Invalid parameters of request"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users [get]
+func (adminRouter *AdminRouter) ListUsers(context echo.Context) error {
+	request := dtos.ListUsersRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid parameters of request"})
+	}
+
+	if request.Page == 0 {
+		request.Page = 1
+	}
+	if request.PageSize == 0 {
+		request.PageSize = defaultUsersPageSize
+	}
+
+	if request.Page < 1 || request.PageSize < 1 || request.PageSize > maxUsersPageSize {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Page must be positive and page size must be from 1 to %d", maxUsersPageSize)})
+	}
+
+	if _, exists := adminRouter.RoleService.Get(request.Role); request.Role != "" && !exists {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid role"})
+	}
+
+	if request.Status != "" && !slices.Contains(userStatuses, request.Status) {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid status"})
+	}
+
+	phonePrefix := strings.ReplaceAll(request.PhonePrefix, " ", "")
+	if phonePrefix != "" {
+		if !phonePrefixRegex.MatchString(phonePrefix) {
+			return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Phone prefix must consist of digits"})
+		}
+
+		// Numbers are stored in E.164 format
+		phonePrefix = "+" + strings.TrimPrefix(phonePrefix, "+")
+	}
+
+	users, total, err := adminRouter.UserRepository.List(context.Request().Context(), repositories.UserFilter{
+		Role:              request.Role,
+		Status:            request.Status,
+		PhoneNumberPrefix: phonePrefix,
+		Offset:            (request.Page - 1) * request.PageSize,
+		Limit:             request.PageSize,
+	})
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving users from database"})
+	}
+
+	items := make([]dtos.AdminUserResponse, 0, len(users))
+	for _, userModel := range users {
+		items = append(items, buildAdminUserResponse(userModel))
+	}
+
+	return context.JSON(http.StatusOK, dtos.UsersPageResponse{
+		Items:    items,
+		Total:    total,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+	})
+}
+
+// GetUser godoc
+// @Title GetUser
+// @Summary User with passed id
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "User"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id} [get]
+func (adminRouter *AdminRouter) GetUser(context echo.Context) error {
+	userModel, err := adminRouter.getRequestedUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+}
+
+// ChangeUserRole godoc
+// @Title ChangeUserRole
+// @Summary Changing primary role of user
+// @Description All sessions of user are revoked, so new role is applied at next login
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Param request body dtos.ChangeRoleRequest true "Dto with new role"
+// @Success 200 {object} dtos.AdminUserResponse "Changed user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid role or admin tries to change own role (error_code: cannot_modify_own_account)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/role [put]
+func (adminRouter *AdminRouter) ChangeUserRole(context echo.Context) error {
+	request := dtos.ChangeRoleRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	if _, exists := adminRouter.RoleService.Get(request.Role); !exists {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Role doesn't exist"})
+	}
+
+	userModel, err := adminRouter.getModifiableUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	if userModel.UserRole == request.Role {
+		return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+	}
+
+	additionalRoles, err := adminRouter.RoleService.GetUserRoles(context.Request().Context(), userModel.Id)
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving roles of user"})
+	}
+
+	oldRole := userModel.UserRole
+	userModel.UserRole = request.Role
+	userModel.UpdatedAt = time.Now()
+
+	event := services.NewUserEvent(services.UserEventRoleChanged, userModel.Id, userModel.UpdatedAt,
+		services.UserRoleChangedEventData{OldRole: oldRole, Role: request.Role, AdditionalRoles: additionalRoles})
+
+	err = adminRouter.AccountManager.ChangeRole(context.Request().Context(), userModel.Id, request.Role, userModel.UpdatedAt, event)
+
+	return adminRouter.revokeSessionsIfSaved(context, userModel, err,
+		fmt.Sprintf("role of user %s was changed from %s to %s", userModel.Id, oldRole, request.Role))
+}
+
+// GetUserRoles godoc
+// @Title GetUserRoles
+// @Summary Primary and additional roles of user
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.UserRolesResponse "Roles of user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/roles [get]
+func (adminRouter *AdminRouter) GetUserRoles(context echo.Context) error {
+	userModel, err := adminRouter.getRequestedUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	additionalRoles, err := adminRouter.RoleService.GetUserRoles(context.Request().Context(), userModel.Id)
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving roles of user"})
+	}
+
+	return context.JSON(http.StatusOK, dtos.UserRolesResponse{PrimaryRole: userModel.UserRole, AdditionalRoles: additionalRoles})
+}
+
+// SetUserRoles godoc
+// @Title SetUserRoles
+// @Summary Replacing additional roles of user
+// @Description Primary role is changed separately. All sessions of user are revoked, so new roles are applied at next login
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Param request body dtos.SetUserRolesRequest true "Dto with all additional roles of user"
+// @Success 200 {object} dtos.UserRolesResponse "Roles of user"
+// @Failure 400 {object} dtos.ErrorDto "Role doesn't exist (error_code: role_not_found) or admin tries to change own roles (cannot_modify_own_account)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/roles [put]
+func (adminRouter *AdminRouter) SetUserRoles(context echo.Context) error {
+	request := dtos.SetUserRolesRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	userModel, err := adminRouter.getModifiableUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	// Primary role is always granted, so it isn't duplicated in additional ones
+	additionalRoles := []string{}
+	for _, roleName := range request.AdditionalRoles {
+		if roleName != userModel.UserRole && !slices.Contains(additionalRoles, roleName) {
+			additionalRoles = append(additionalRoles, roleName)
+		}
+	}
+
+	slices.Sort(additionalRoles)
+
+	ctx := context.Request().Context()
+
+	err = adminRouter.setUserRoles(ctx, userModel, additionalRoles)
+	if errors.Is(err, services.ErrRoleNotFound) {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Role doesn't exist", ErrorCode: dtos.ErrorCodeRoleNotFound})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while setting roles of user"})
+	}
+
+	err = adminRouter.AccountManager.RevokeAllSessions(ctx, userModel.Id)
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while revoking sessions of user"})
+	}
+
+	logAdminAction(adminRouter.Logger, context, fmt.Sprintf("additional roles of user %s were set to %v", userModel.Id, additionalRoles))
+	return context.JSON(http.StatusOK, dtos.UserRolesResponse{PrimaryRole: userModel.UserRole, AdditionalRoles: additionalRoles})
+}
+
+// SuspendUser godoc
+// @Title SuspendUser
+// @Summary Suspending user
+// @Description All sessions of user are revoked, suspended user can't log in until unsuspended
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "Suspended user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user or admin tries to suspend own account (error_code: cannot_modify_own_account)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func (adminRouter *AdminRouter) SuspendUser(context echo.Context) error {
+	return adminRouter.blockUser(context, entities.UserStatusSuspended)
+}
+
+// UnsuspendUser godoc
+// @Title UnsuspendUser
+// @Summary Lifting suspension of user
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "Active user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/unsuspend [post]
+func (adminRouter *AdminRouter) UnsuspendUser(context echo.Context) error {
+	return adminRouter.unblockUser(context, entities.UserStatusSuspended)
+}
+
+// BanUser godoc
+// @Title BanUser
+// @Summary Banning user for abuse
+// @Description All sessions of user are revoked, banned user can't log in until unbanned. Phone number of banned user can't be used for new account
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "Banned user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user or admin tries to ban own account (error_code: cannot_modify_own_account)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/ban [post]
+func (adminRouter *AdminRouter) BanUser(context echo.Context) error {
+	return adminRouter.blockUser(context, entities.UserStatusBanned)
+}
+
+// UnbanUser godoc
+// @Title UnbanUser
+// @Summary Lifting ban of user
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "Active user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/unban [post]
+func (adminRouter *AdminRouter) UnbanUser(context echo.Context) error {
+	return adminRouter.unblockUser(context, entities.UserStatusBanned)
+}
+
+// ApproveUser godoc
+// @Title ApproveUser
+// @Summary Approving user who registered with role requiring approval
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.AdminUserResponse "Active user"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id}/approve [post]
+func (adminRouter *AdminRouter) ApproveUser(context echo.Context) error {
+	return adminRouter.unblockUser(context, entities.UserStatusPendingApproval)
+}
+
+// DeleteUser godoc
+// @Title DeleteUser
+// @Summary Deleting user
+// @Description Same as deletion of account by user: user is marked deleted, its sessions are revoked and data is purged after grace period
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Param id path string true "Id of user"
+// @Success 200 {object} dtos.DeleteAccountResponse "User is deleted, giving moment of purge"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of user or admin tries to delete own account (error_code: cannot_modify_own_account)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "User is already deleted (error_code: account_deleted)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/users/{id} [delete]
+func (adminRouter *AdminRouter) DeleteUser(context echo.Context) error {
+	userModel, err := adminRouter.getModifiableUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	purgeAt, err := adminRouter.AccountManager.DeleteAccount(context.Request().Context(), userModel.Id, false)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while deleting user"})
+	}
+
+	logAdminAction(adminRouter.Logger, context, fmt.Sprintf("user %s was deleted", userModel.Id))
+	return context.JSON(http.StatusOK, dtos.DeleteAccountResponse{PurgeAt: purgeAt})
+}
+
+// RotateSigningKey godoc
+// @Title RotateSigningKey
+// @Summary Replacing current signing key with new one
+// @Description Old key stays valid for verification until tokens signed by it expire. Available only when keys are managed by service (see tokens.key_rotation_interval_hours)
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {object} dtos.RotateKeyResponse "Id of new signing key"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 409 {object} dtos.ErrorDto "Signing key is taken from config (error_code: key_rotation_disabled)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/keys/rotate [post]
+func (adminRouter *AdminRouter) RotateSigningKey(context echo.Context) error {
+	err := adminRouter.Keyring.Rotate(context.Request().Context())
+	if errors.Is(err, services.ErrKeyRotationDisabled) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Signing key is taken from config and can't be rotated", ErrorCode: dtos.ErrorCodeKeyRotationDisabled})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(fmt.Errorf("while rotating signing key happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while rotating signing key"})
+	}
+
+	keyId := adminRouter.Keyring.Current().Id
+	logAdminAction(adminRouter.Logger, context, fmt.Sprintf("signing key was rotated, new kid is %s", keyId))
+
+	return context.JSON(http.StatusOK, dtos.RotateKeyResponse{KeyId: keyId})
+}
+
+// Returns user from "id" path parameter. If there is no such user, responds with error and returns nil and result of responding
+func (adminRouter *AdminRouter) getRequestedUser(context echo.Context) (*entities.User, error) {
+	userId, err := uuid.Parse(context.Param("id"))
+	if err != nil {
+		return nil, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid id of user"})
+	}
+
+	userModel, err := adminRouter.UserRepository.GetById(context.Request().Context(), userId)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return nil, context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
+	}
+
+	return userModel, nil
+}
+
+// Same as getRequestedUser, but also refuses deleted users and own account of admin,
+// so admin can't lock own account out
+func (adminRouter *AdminRouter) getModifiableUser(context echo.Context) (*entities.User, error) {
+	userModel, err := adminRouter.getRequestedUser(context)
+	if userModel == nil {
+		return nil, err
+	}
+
+	claims := middlewares.GetClaims(context)
+	if claims != nil && claims.UserId == userModel.Id {
+		return nil, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Admin can't change own account through admin API", ErrorCode: dtos.ErrorCodeCannotModifyOwnAccount})
+	}
+
+	if userModel.Status == entities.UserStatusDeleted {
+		return nil, context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "User is deleted", ErrorCode: dtos.ErrorCodeAccountDeleted})
+	}
+
+	return userModel, nil
+}
+
+// Sets passed blocking status (suspended or banned) and revokes sessions of user
+func (adminRouter *AdminRouter) blockUser(context echo.Context, status string) error {
+	userModel, err := adminRouter.getModifiableUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	if userModel.Status == status {
+		return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+	}
+
+	userModel.Status = status
+	userModel.UpdatedAt = time.Now()
+
+	err = adminRouter.UserRepository.UpdateStatus(context.Request().Context(), userModel.Id, status, userModel.UpdatedAt)
+
+	return adminRouter.revokeSessionsIfSaved(context, userModel, err, fmt.Sprintf("user %s got status %s", userModel.Id, status))
+}
+
+// Makes user active again if it has passed blocking status, otherwise leaves user as is
+func (adminRouter *AdminRouter) unblockUser(context echo.Context, status string) error {
+	userModel, err := adminRouter.getModifiableUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	if userModel.Status != status {
+		return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+	}
+
+	userModel.Status = entities.UserStatusActive
+	userModel.UpdatedAt = time.Now()
+
+	err = adminRouter.UserRepository.UpdateStatus(context.Request().Context(), userModel.Id, userModel.Status, userModel.UpdatedAt)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while updating user"})
+	}
+
+	adminRouter.AccountManager.UserStatusChecker.Forget(userModel.Id)
+
+	logAdminAction(adminRouter.Logger, context, fmt.Sprintf("status %s of user %s was lifted", status, userModel.Id))
+	return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+}
+
+// Revokes sessions of user if its role or status was saved (err of saving is nil), so tokens with old role or status can't be used.
+// Otherwise responds with error of saving
+func (adminRouter *AdminRouter) revokeSessionsIfSaved(context echo.Context, userModel *entities.User, err error, action string) error {
+	ctx := context.Request().Context()
+
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while updating user"})
+	}
+
+	err = adminRouter.AccountManager.RevokeAllSessions(ctx, userModel.Id)
+	if err != nil {
+		adminRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while revoking sessions of user"})
+	}
+
+	logAdminAction(adminRouter.Logger, context, action)
+	return context.JSON(http.StatusOK, buildAdminUserResponse(userModel))
+}
+
+// Replaces additional roles of user together with saving event about it
+func (adminRouter *AdminRouter) setUserRoles(ctx context.Context, userModel *entities.User, additionalRoles []string) error {
+	return adminRouter.AccountManager.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := adminRouter.RoleService.SetUserRoles(ctx, userModel.Id, additionalRoles)
+		if err != nil {
+			return err
+		}
+
+		return adminRouter.AccountManager.KafkaProducer.PublishUserEvent(ctx, services.NewUserEvent(services.UserEventRoleChanged, userModel.Id, time.Now(),
+			services.UserRoleChangedEventData{OldRole: userModel.UserRole, Role: userModel.UserRole, AdditionalRoles: additionalRoles}))
+	})
+}
+
+// Logs action with id of admin who made it
+func logAdminAction(logger *zap.Logger, context echo.Context, action string) {
+	adminId := ""
+	if claims := middlewares.GetClaims(context); claims != nil {
+		adminId = claims.UserId.String()
+	}
+
+	logger.Info(action, zap.String("admin_id", adminId))
+}
+
+func buildAdminUserResponse(userModel *entities.User) dtos.AdminUserResponse {
+	return dtos.AdminUserResponse{
+		Id:          userModel.Id.String(),
+		PhoneNumber: userModel.PhoneNumber,
+		Role:        userModel.UserRole,
+		DisplayName: userModel.DisplayName,
+		Status:      userModel.Status,
+		CreatedAt:   userModel.CreatedAt,
+		UpdatedAt:   userModel.UpdatedAt,
+		LastLoginAt: userModel.LastLoginAt,
+		DeletedAt:   userModel.DeletedAt,
+	}
+}
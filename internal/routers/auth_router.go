@@ -1,14 +1,19 @@
 package routers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
-	"slices"
+	"strings"
+	"time"
 
 	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/middlewares"
 	"github.com/WebChads/AuthService/internal/models/dtos"
 	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/phone"
 	"github.com/WebChads/AuthService/internal/services"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -16,41 +21,64 @@ import (
 )
 
 type AuthRouter struct {
-	Logger         *zap.Logger
-	TokenHandler   services.TokenHandler
-	UserRepository repositories.UserRepository
-	KafkaProducer  services.KafkaProducer
-	KafkaConsumer  services.KafkaConsumer
+	Logger              *zap.Logger
+	TokenHandler        services.TokenHandler
+	RefreshTokenHandler services.RefreshTokenHandler
+	UserRepository      repositories.UserRepository
+	PhoneParser         phone.Parser
+	SmsVerifier         *SmsVerifier
+	RoleService         services.RoleService
+	InviteCodeService   services.InviteCodeService
+	TransactionRunner   repositories.TransactionRunner
+	KafkaProducer       services.KafkaProducer
+	AccountManager      *AccountManager
 }
 
 func NewAuthRouter(logger *zap.Logger,
 	tokenHandler services.TokenHandler,
+	refreshTokenHandler services.RefreshTokenHandler,
 	userRepository repositories.UserRepository,
+	phoneParser phone.Parser,
+	smsVerifier *SmsVerifier,
+	roleService services.RoleService,
+	inviteCodeService services.InviteCodeService,
+	transactionRunner repositories.TransactionRunner,
 	kafkaProducer services.KafkaProducer,
-	kafkaConsumer services.KafkaConsumer) *AuthRouter {
+	accountManager *AccountManager) *AuthRouter {
 
 	authRouter := &AuthRouter{
-		Logger:         logger,
-		TokenHandler:   tokenHandler,
-		UserRepository: userRepository,
-		KafkaProducer:  kafkaProducer,
-		KafkaConsumer:  kafkaConsumer}
+		Logger:              logger,
+		TokenHandler:        tokenHandler,
+		RefreshTokenHandler: refreshTokenHandler,
+		UserRepository:      userRepository,
+		PhoneParser:         phoneParser,
+		SmsVerifier:         smsVerifier,
+		RoleService:         roleService,
+		InviteCodeService:   inviteCodeService,
+		TransactionRunner:   transactionRunner,
+		KafkaProducer:       kafkaProducer,
+		AccountManager:      accountManager}
 
 	return authRouter
 }
 
-var possibleRoles = []string{"Player", "Trainer"}
+// Length of code depends on sms.code_length, wrong code is rejected by storage anyway
+var smsCodeRegex = regexp.MustCompile(`^\d{4,10}$`)
 
 // GenerateToken godoc
 // @Title GenerateToken
-// @Summary Generate a new authentication token
-// @Description Generates a new JWT (or other) token for user authentication
+// @Summary Generate a new authentication token for existing user (for developers)
+// @Description Only admin can call it. Role of token is taken from user, so token gives no more rights than user has
 // @Tags Authentication
 // @Accept json
 // @Produce json
+// @Security JwtBearer
 // @Param request body dtos.GenerateTokenRequest true "Token generation parameters"
 // @Success 200 {object} dtos.TokenResponse "Successfully generated token"
 // @Failure 400 {object} dtos.ErrorDto "Invalid UserId format (must be UUID)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden) or user can't log in (account_deleted, account_suspended, account_banned, account_pending_approval)"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist"
 // @Failure 500 {object} dtos.ErrorDto "Happened internal error"
 // @Router /api/v1/auth/generate-token [post]
 func (authRouter *AuthRouter) GenerateToken(context echo.Context) error {
@@ -63,7 +91,24 @@ func (authRouter *AuthRouter) GenerateToken(context echo.Context) error {
 		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid UserId format (must be UUID)"})
 	}
 
-	token, err := authRouter.TokenHandler.GenerateToken(parsedUuid, tokenRequest.Role)
+	ctx := context.Request().Context()
+
+	userModel, err := authRouter.UserRepository.GetById(ctx, parsedUuid)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		authRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+	if !canLogIn {
+		return err
+	}
+
+	token, err := authRouter.TokenHandler.GenerateToken(ctx, userModel.Id, userModel.UserRole)
 	if err != nil {
 		authRouter.Logger.Error(err.Error())
 		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
@@ -74,35 +119,54 @@ func (authRouter *AuthRouter) GenerateToken(context echo.Context) error {
 
 // Register godoc
 // @Title Register
-// @Summary Create user entity in database, making him ready to log in
+// @Summary Create user entity in database, making them ready to log in
+// @Description Role with invite_code registration policy requires invite code. User with role with approval policy can't log in until admin approves them, unless valid invite code is passed
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body dtos.RegisterRequest true "Register parameters"
 // @Success 200 "Successfully created user in db"
-// @Failure 400 {object} dtos.ErrorDto "Invalid phone number"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)"
 // @Failure 400 {object} dtos.ErrorDto "Invalid role"
+// @Failure 400 {object} dtos.ErrorDto "Role requires invite code (error_code: invite_code_required) or invite code is invalid, expired or used up (invite_code_invalid)"
+// @Failure 409 {object} dtos.ErrorDto "User with this phone number already exists (error_code: user_already_exists)"
 // @Failure 500 {object} dtos.ErrorDto "Happened internal error"
 // @Router /api/v1/auth/register [post]
 func (authRouter *AuthRouter) Register(context echo.Context) error {
 	request := dtos.RegisterRequest{}
 	context.Bind(&request)
 
-	phoneNumberRegex := `^(8|\+7)(\s|\(|-)?(\d{3})(\s|\)|-)?(\d{3})(\s|-)?(\d{2})(\s|-)?(\d{2})$`
-	isPhoneNumberCorrect, err := regexp.MatchString(phoneNumberRegex, request.PhoneNumber)
-	if err != nil || !isPhoneNumberCorrect {
-		authRouter.Logger.Error(fmt.Errorf("user sent invalid phone number: %s", request.PhoneNumber).Error())
-		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid phone number"})
+	normalizedPhoneNumber, err := authRouter.PhoneParser.Normalize(request.PhoneNumber)
+	if err != nil {
+		return respondInvalidPhoneNumber(context, authRouter.Logger, request.PhoneNumber, err)
 	}
+	request.PhoneNumber = normalizedPhoneNumber
 
-	if !slices.Contains(possibleRoles, request.Role) {
+	role, exists := authRouter.RoleService.Get(request.Role)
+	if !exists || !role.IsSelfAssignable {
 		authRouter.Logger.Error(fmt.Errorf("user sent invalid role: %s", request.Role).Error())
 		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid role"})
 	}
 
-	err = authRouter.UserRepository.Add(&entities.User{Id: uuid.New(), PhoneNumber: request.PhoneNumber, UserRole: request.Role})
+	status, canRegister, err := authRouter.checkRegistrationPolicy(context, role, request.InviteCode)
+	if !canRegister {
+		return err
+	}
+
+	newUser := &entities.User{Id: uuid.New(), PhoneNumber: request.PhoneNumber, UserRole: request.Role, Status: status}
+
+	err = authRouter.addUser(context.Request().Context(), newUser, role, request.InviteCode)
+	if errors.Is(err, services.ErrInviteCodeInvalid) {
+		return respondInviteCodeInvalid(context)
+	}
+
+	if errors.Is(err, repositories.ErrUserAlreadyExists) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "User with this phone number already exists", ErrorCode: dtos.ErrorCodeUserAlreadyExists})
+	}
+
 	if err != nil {
-		return context.JSON(400, dtos.ErrorDto{ErrorMessage: fmt.Errorf("while adding user in db happened error: %w", err).Error()})
+		authRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while adding user in db"})
 	}
 
 	return context.NoContent(200)
@@ -116,26 +180,21 @@ func (authRouter *AuthRouter) Register(context echo.Context) error {
 // @Produce json
 // @Param request body dtos.SendSmsCodeRequest true "Dto with phone number"
 // @Success 200 "Successfully sent code"
-// @Failure 400 {object} dtos.ErrorDto "Invalid phone number"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)"
+// @Failure 429 {object} dtos.ErrorDto "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)"
 // @Failure 500 {object} dtos.ErrorDto "Happened internal error"
 // @Router /api/v1/auth/send-sms-code [post]
 func (authRouter *AuthRouter) SendSmsCode(context echo.Context) error {
 	request := dtos.SendSmsCodeRequest{}
 	context.Bind(&request)
 
-	phoneNumberRegex := `^(8|\+7)(\s|\(|-)?(\d{3})(\s|\)|-)?(\d{3})(\s|-)?(\d{2})(\s|-)?(\d{2})$`
-	isPhoneNumberCorrect, err := regexp.MatchString(phoneNumberRegex, request.PhoneNumber)
-	if err != nil || !isPhoneNumberCorrect {
-		authRouter.Logger.Error(fmt.Errorf("user sent invalid phone number: %s", request.PhoneNumber).Error())
-		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid phone number"})
-	}
-
-	err = authRouter.KafkaProducer.SendPhoneNumber(request.PhoneNumber)
+	normalizedPhoneNumber, err := authRouter.PhoneParser.Normalize(request.PhoneNumber)
 	if err != nil {
-		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while sending sms to this phone number"})
+		return respondInvalidPhoneNumber(context, authRouter.Logger, request.PhoneNumber, err)
 	}
+	request.PhoneNumber = normalizedPhoneNumber
 
-	return context.NoContent(200)
+	return authRouter.SmsVerifier.SendCode(context, request.PhoneNumber)
 }
 
 // ValidateToken godoc
@@ -154,7 +213,7 @@ func (authRouter *AuthRouter) ValidateToken(context echo.Context) error {
 
 	authRouter.Logger.Info(fmt.Sprintf("token sent in validate token: %s", tokenRequest.Token))
 
-	isValid, err := authRouter.TokenHandler.ValidateToken(tokenRequest.Token)
+	isValid, err := authRouter.TokenHandler.ValidateToken(context.Request().Context(), tokenRequest.Token)
 
 	if err != nil {
 		isValid = false
@@ -163,64 +222,570 @@ func (authRouter *AuthRouter) ValidateToken(context echo.Context) error {
 	return context.JSON(200, dtos.ValidateTokenResponse{IsValid: isValid})
 }
 
+// IntrospectToken godoc
+// @Title IntrospectToken
+// @Summary Returning state and claims of token (RFC 7662)
+// @Description For valid access token returns "active": true with its claims, for any invalid, expired or revoked token - only "active": false.
+// @Description Caller authenticates as one of tokens.introspection_clients with HTTP Basic (client id and secret)
+// @Tags Authentication
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Security ClientBasic
+// @Param request body dtos.IntrospectTokenRequest true "Dto with token"
+// @Success 200 {object} dtos.IntrospectTokenResponse "State and claims of token"
+// @Failure 400 {object} dtos.ErrorDto "Token is empty"
+// @Failure 401 {object} dtos.ErrorDto "Client credentials are missing or invalid (error_code: invalid_client)"
+// @Router /api/v1/auth/introspect [post]
+func (authRouter *AuthRouter) IntrospectToken(context echo.Context) error {
+	request := dtos.IntrospectTokenRequest{}
+	context.Bind(&request)
+
+	if request.Token == "" {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Token is empty"})
+	}
+
+	claims, err := authRouter.TokenHandler.ParseToken(context.Request().Context(), request.Token)
+	if err != nil {
+		return context.JSON(200, dtos.IntrospectTokenResponse{Active: false})
+	}
+
+	response := dtos.IntrospectTokenResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		Role:      claims.UserRole,
+
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scope:       strings.Join(claims.Permissions, " "),
+	}
+
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+
+	return context.JSON(200, response)
+}
+
 // VerifySmsCode godoc
 // @Title VerifySmsCode
 // @Summary Verifying SMS code if it is what was sent to user
+// @Description Code is checked before registration and status of user, so without valid code response doesn't reveal whether phone number is registered.
+// @Description Code is spent even if phone number is not registered, new user should use login/complete instead
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body dtos.VerifySmsCodeRequest true "Dto with phone number and SMS code"
-// @Success 200 "Valid SMS code, giving token"
-// @Failure 400 {object} dtos.ErrorDto "Invalid phone number"
+// @Success 200 {object} dtos.TokenPairResponse "Valid SMS code, giving access and refresh tokens"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)"
 // @Failure 400 {object} dtos.ErrorDto "Invalid SMS code format"
 // @Failure 400 {object} dtos.ErrorDto "Invalid SMS code"
+// @Failure 403 {object} dtos.ErrorDto "Account is deleted (error_code: account_deleted) suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)"
+// @Failure 404 {object} dtos.ErrorDto "Code is valid, but user is not registered (error_code: user_not_registered)"
+// @Failure 429 {object} dtos.ErrorDto "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)"
 // @Failure 500 {object} dtos.ErrorDto "Happened internal error"
 // @Router /api/v1/auth/verify-sms-code [post]
 func (authRouter *AuthRouter) VerifySmsCode(context echo.Context) error {
 	request := dtos.VerifySmsCodeRequest{}
 	context.Bind(&request)
 
-	phoneNumberRegex := `^(8|\+7)(\s|\(|-)?(\d{3})(\s|\)|-)?(\d{3})(\s|-)?(\d{2})(\s|-)?(\d{2})$`
-	isPhoneNumberCorrect, err := regexp.MatchString(phoneNumberRegex, request.PhoneNumber)
-	if err != nil || !isPhoneNumberCorrect {
-		authRouter.Logger.Error(fmt.Errorf("user sent invalid phone number: %s", request.PhoneNumber).Error())
-		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid phone number"})
+	normalizedPhoneNumber, err := authRouter.PhoneParser.Normalize(request.PhoneNumber)
+	if err != nil {
+		return respondInvalidPhoneNumber(context, authRouter.Logger, request.PhoneNumber, err)
 	}
+	request.PhoneNumber = normalizedPhoneNumber
 
-	smsCodeRegex := `^\d{4}$`
-	isSmsCodeFormatCorrect, err := regexp.MatchString(smsCodeRegex, request.SmsCode)
-	if err != nil || !isSmsCodeFormatCorrect {
+	if !smsCodeRegex.MatchString(request.SmsCode) {
 		authRouter.Logger.Error(fmt.Errorf("user sent invalid sms code format: %s", request.SmsCode).Error())
 		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid SMS code format"})
 	}
 
-	smsCodeFromKafka, exists := authRouter.KafkaConsumer.GetSmsCode(request.PhoneNumber)
-	if !exists {
-		authRouter.Logger.Error(fmt.Errorf("for user with phone number %s wasn't produced any sms code", request.PhoneNumber).Error())
-		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Sms code wasn't requested"})
+	// Code is checked first, so only owner of phone number learns whether it's registered
+	isAccepted, err := authRouter.SmsVerifier.CheckCode(context, request.PhoneNumber, request.SmsCode)
+	if !isAccepted {
+		return err
 	}
 
-	if smsCodeFromKafka != request.SmsCode {
-		authRouter.Logger.Error(fmt.Errorf("invalid sms code for user with phone number %s. Sent: %s. Actual: %s", request.PhoneNumber, request.SmsCode, smsCodeFromKafka).Error())
-		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid SMS code"})
+	ctx := context.Request().Context()
+
+	userModel, err := authRouter.UserRepository.Get(ctx, request.PhoneNumber)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		authRouter.Logger.Error(fmt.Errorf("user with phone number %s is not registered", request.PhoneNumber).Error())
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User with this phone number is not registered", ErrorCode: dtos.ErrorCodeUserNotRegistered})
 	}
 
-	userModel, err := authRouter.UserRepository.Get(request.PhoneNumber)
 	if err != nil {
 		authRouter.Logger.Error(fmt.Errorf("while retrieving user from database happened error: %w", err).Error())
 		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
 	}
 
+	canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+	if !canLogIn {
+		return err
+	}
+
+	tokenPair, err := authRouter.logIn(ctx, userModel, false)
+	if err != nil {
+		authRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while generating token for user"})
+	}
+
+	return context.JSON(200, tokenPair)
+}
+
+// LoginStart godoc
+// @Title LoginStart
+// @Summary Starting passwordless login: sending sms code to phone number
+// @Description Works for registered and new phone numbers alike, so it doesn't reveal whether phone number is registered
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body dtos.LoginStartRequest true "Dto with phone number"
+// @Success 200 "Successfully sent code"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)"
+// @Failure 429 {object} dtos.ErrorDto "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/auth/login/start [post]
+func (authRouter *AuthRouter) LoginStart(context echo.Context) error {
+	request := dtos.LoginStartRequest{}
+	context.Bind(&request)
+
+	normalizedPhoneNumber, err := authRouter.PhoneParser.Normalize(request.PhoneNumber)
+	if err != nil {
+		return respondInvalidPhoneNumber(context, authRouter.Logger, request.PhoneNumber, err)
+	}
+
+	return authRouter.SmsVerifier.SendCode(context, normalizedPhoneNumber)
+}
+
+// LoginComplete godoc
+// @Title LoginComplete
+// @Summary Completing passwordless login: verifying sms code and giving tokens
+// @Description If phone number is not registered yet, user is created with passed role on successful verification.
+// @Description Registration policy of role is applied the same way as in register request.
+// @Description Account deleted by user is restored if restore_account is set and grace period is not over, account deleted by admin can't be restored
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body dtos.LoginCompleteRequest true "Dto with phone number, SMS code and role (role is required only for new user)"
+// @Success 200 {object} dtos.LoginCompleteResponse "Valid SMS code, giving access and refresh tokens"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number) or country is not supported (phone_country_not_allowed)"
+// @Failure 400 {object} dtos.ErrorDto "Invalid SMS code format"
+// @Failure 400 {object} dtos.ErrorDto "Phone number is not registered and role is missing or invalid (error_code: role_required)"
+// @Failure 400 {object} dtos.ErrorDto "Role requires invite code (error_code: invite_code_required) or invite code is invalid, expired or used up (invite_code_invalid)"
+// @Failure 400 {object} dtos.ErrorDto "Invalid SMS code"
+// @Failure 403 {object} dtos.ErrorDto "Account is deleted (error_code: account_deleted) suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)"
+// @Failure 429 {object} dtos.ErrorDto "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/auth/login/complete [post]
+func (authRouter *AuthRouter) LoginComplete(context echo.Context) error {
+	request := dtos.LoginCompleteRequest{}
+	context.Bind(&request)
+
+	normalizedPhoneNumber, err := authRouter.PhoneParser.Normalize(request.PhoneNumber)
+	if err != nil {
+		return respondInvalidPhoneNumber(context, authRouter.Logger, request.PhoneNumber, err)
+	}
+	request.PhoneNumber = normalizedPhoneNumber
+
+	if !smsCodeRegex.MatchString(request.SmsCode) {
+		authRouter.Logger.Error(fmt.Errorf("user sent invalid sms code format: %s", request.SmsCode).Error())
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid SMS code format"})
+	}
+
+	ctx := context.Request().Context()
+
+	userModel, err := authRouter.UserRepository.Get(ctx, request.PhoneNumber)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		userModel, err = nil, nil
+	}
+
+	if err != nil {
+		authRouter.Logger.Error(fmt.Errorf("while retrieving user from database happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
+	}
+
+	// Checked before code is consumed, so client can resend request with role (and invite code) and the same code
+	var role *entities.Role
+	var status string
 	if userModel == nil {
-		authRouter.Logger.Error(fmt.Errorf("somehow user model is nil, was requested for phone number: %s, good luck in debugging", request.PhoneNumber).Error())
+		var exists bool
+		role, exists = authRouter.RoleService.Get(request.Role)
+		if !exists || !role.IsSelfAssignable {
+			authRouter.Logger.Error(fmt.Errorf("new user sent invalid role: %s", request.Role).Error())
+			return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Role is required for new user", ErrorCode: dtos.ErrorCodeRoleRequired})
+		}
+
+		canRegister := false
+		status, canRegister, err = authRouter.checkRegistrationPolicy(context, role, request.InviteCode)
+		if !canRegister {
+			return err
+		}
+	}
+
+	isRestoring := userModel != nil && userModel.Status == entities.UserStatusDeleted && request.RestoreAccount
+	if userModel != nil && !isRestoring {
+		canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+		if !canLogIn {
+			return err
+		}
+	}
+
+	isAccepted, err := authRouter.SmsVerifier.CheckCode(context, request.PhoneNumber, request.SmsCode)
+	if !isAccepted {
+		return err
+	}
+
+	if isRestoring {
+		userId := userModel.Id
+
+		userModel, err = authRouter.AccountManager.RestoreAccount(ctx, userId)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			authRouter.Logger.Warn(fmt.Sprintf("user %s tried to restore account deleted by admin or already purged", userId))
+			return context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Account is deleted", ErrorCode: dtos.ErrorCodeAccountDeleted})
+		}
+
+		if err != nil {
+			authRouter.Logger.Error(err.Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while restoring user"})
+		}
+
+		// Account gets back status it had before deletion, e.g. it can still wait for approval
+		canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+		if !canLogIn {
+			return err
+		}
+	}
+
+	isNewUser := userModel == nil
+	if isNewUser {
+		userModel, isNewUser, err = authRouter.registerOnLogin(ctx, request.PhoneNumber, role, status, request.InviteCode)
+		if errors.Is(err, services.ErrInviteCodeInvalid) {
+			return respondInviteCodeInvalid(context)
+		}
+
+		if err != nil {
+			authRouter.Logger.Error(err.Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while adding user in db"})
+		}
+
+		// User registered with role requiring approval gets no tokens until approved
+		canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+		if !canLogIn {
+			return err
+		}
+	}
+
+	tokenPair, err := authRouter.logIn(ctx, userModel, isNewUser)
+	if err != nil {
+		authRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while generating token for user"})
+	}
+
+	return context.JSON(200, dtos.LoginCompleteResponse{Token: tokenPair.Token, RefreshToken: tokenPair.RefreshToken, IsNewUser: isNewUser})
+}
+
+// RefreshToken godoc
+// @Title RefreshToken
+// @Summary Exchanging refresh token for new pair of access and refresh tokens
+// @Description Refresh token is single-use: it is rotated on every call. Reusing already rotated token revokes whole token family (all tokens issued since login)
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body dtos.RefreshTokenRequest true "Dto with refresh token"
+// @Success 200 {object} dtos.TokenPairResponse "New access and refresh tokens"
+// @Failure 400 {object} dtos.ErrorDto "Refresh token is empty"
+// @Failure 401 {object} dtos.ErrorDto "Invalid refresh token"
+// @Failure 401 {object} dtos.ErrorDto "Refresh token reuse detected"
+// @Failure 403 {object} dtos.ErrorDto "Account is deleted (error_code: account_deleted), suspended (account_suspended), banned (account_banned) or waits for approval (account_pending_approval)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/auth/refresh [post]
+func (authRouter *AuthRouter) RefreshToken(context echo.Context) error {
+	request := dtos.RefreshTokenRequest{}
+	context.Bind(&request)
+
+	if request.RefreshToken == "" {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Refresh token is empty"})
+	}
+
+	ctx := context.Request().Context()
+
+	userId, refreshToken, err := authRouter.RefreshTokenHandler.Rotate(ctx, request.RefreshToken)
+	if errors.Is(err, services.ErrRefreshTokenReused) {
+		authRouter.Logger.Warn("refresh token reuse detected, token family was revoked")
+		return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Refresh token reuse detected"})
+	}
+
+	if errors.Is(err, services.ErrRefreshTokenInvalid) {
+		return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Invalid refresh token"})
+	}
+
+	if err != nil {
+		authRouter.Logger.Error(fmt.Errorf("while rotating refresh token happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	userModel, err := authRouter.UserRepository.GetById(ctx, userId)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Invalid refresh token"})
+	}
+
+	if err != nil {
+		authRouter.Logger.Error(fmt.Errorf("while retrieving user from database happened error: %w", err).Error())
 		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
 	}
 
-	token, err := authRouter.TokenHandler.GenerateToken(userModel.Id, userModel.UserRole)
+	// Sessions are revoked when user is blocked, but status can also be changed right in database
+	canLogIn, err := authRouter.checkUserCanLogIn(context, userModel)
+	if !canLogIn {
+		return err
+	}
+
+	token, err := authRouter.TokenHandler.GenerateToken(ctx, userModel.Id, userModel.UserRole)
 	if err != nil {
 		authRouter.Logger.Error(fmt.Errorf("error happened while generating token for user with uuid %s: %w", userModel.Id, err).Error())
 		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while generating token for user"})
 	}
 
-	return context.JSON(200, dtos.TokenResponse{Token: token})
+	return context.JSON(200, dtos.TokenPairResponse{Token: token, RefreshToken: refreshToken})
+}
+
+// Logout godoc
+// @Title Logout
+// @Summary Revoking access token from Authorization header (and session of refresh token if it's passed)
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.LogoutRequest false "Dto with refresh token of session"
+// @Success 200 "Successfully logged out"
+// @Failure 401 {object} dtos.ErrorDto "Invalid token"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/auth/logout [post]
+func (authRouter *AuthRouter) Logout(context echo.Context) error {
+	request := dtos.LogoutRequest{}
+	context.Bind(&request)
+
+	token, exists := middlewares.ExtractBearerToken(context)
+	if !exists {
+		return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Authorization header with bearer token is required"})
+	}
+
+	ctx := context.Request().Context()
+
+	isValid, err := authRouter.TokenHandler.ValidateToken(ctx, token)
+	if err != nil || !isValid {
+		return context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Invalid token"})
+	}
+
+	err = authRouter.TokenHandler.RevokeToken(ctx, token)
+	if err != nil {
+		authRouter.Logger.Error(fmt.Errorf("while revoking access token happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	if request.RefreshToken != "" {
+		err = authRouter.RefreshTokenHandler.Revoke(ctx, request.RefreshToken)
+		if err != nil {
+			authRouter.Logger.Error(fmt.Errorf("while revoking refresh token happened error: %w", err).Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+		}
+	}
+
+	return context.NoContent(200)
+}
+
+// RevokeToken godoc
+// @Title RevokeToken
+// @Summary Revoking access or refresh token (RFC 7009)
+// @Description Invalid or unknown tokens are not reported as error, as RFC 7009 requires. Revoking refresh token closes its whole session
+// @Tags Authentication
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param request body dtos.RevokeTokenRequest true "Dto with token and optional hint of its type"
+// @Success 200 "Token is revoked"
+// @Failure 400 {object} dtos.ErrorDto "Token is empty"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/auth/revoke [post]
+func (authRouter *AuthRouter) RevokeToken(context echo.Context) error {
+	request := dtos.RevokeTokenRequest{}
+	context.Bind(&request)
+
+	if request.Token == "" {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Token is empty"})
+	}
+
+	ctx := context.Request().Context()
+
+	if request.TokenTypeHint != "refresh_token" {
+		err := authRouter.TokenHandler.RevokeToken(ctx, request.Token)
+		if err == nil {
+			return context.NoContent(200)
+		}
+
+		if !errors.Is(err, services.ErrTokenInvalid) {
+			authRouter.Logger.Error(fmt.Errorf("while revoking access token happened error: %w", err).Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+		}
+	}
+
+	// Not an access token - trying it as refresh token
+	err := authRouter.RefreshTokenHandler.Revoke(ctx, request.Token)
+	if err != nil {
+		authRouter.Logger.Error(fmt.Errorf("while revoking refresh token happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	return context.NoContent(200)
+}
+
+// Checks status of user before tokens are issued (at login - before sms code is consumed).
+// If user can't log in, responds with error and returns false and result of responding
+func (authRouter *AuthRouter) checkUserCanLogIn(context echo.Context, userModel *entities.User) (bool, error) {
+	switch userModel.Status {
+	case entities.UserStatusDeleted:
+		authRouter.Logger.Warn(fmt.Sprintf("deleted user %s tried to log in", userModel.Id))
+		return false, context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Account is deleted", ErrorCode: dtos.ErrorCodeAccountDeleted})
+	case entities.UserStatusSuspended:
+		authRouter.Logger.Warn(fmt.Sprintf("suspended user %s tried to log in", userModel.Id))
+		return false, context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Account is suspended", ErrorCode: dtos.ErrorCodeAccountSuspended})
+	case entities.UserStatusBanned:
+		authRouter.Logger.Warn(fmt.Sprintf("banned user %s tried to log in", userModel.Id))
+		return false, context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Account is banned", ErrorCode: dtos.ErrorCodeAccountBanned})
+	case entities.UserStatusPendingApproval:
+		authRouter.Logger.Info(fmt.Sprintf("user %s waiting for approval tried to log in", userModel.Id))
+		return false, context.JSON(http.StatusForbidden, dtos.ErrorDto{ErrorMessage: "Account waits for approval by admin", ErrorCode: dtos.ErrorCodeAccountPendingApproval})
+	}
+
+	return true, nil
+}
+
+// Records login of user and generates access token and refresh token which starts new session
+func (authRouter *AuthRouter) logIn(ctx context.Context, userModel *entities.User, isNewUser bool) (dtos.TokenPairResponse, error) {
+	// Login shouldn't fail only because time of login wasn't saved
+	err := authRouter.recordLogin(ctx, userModel, isNewUser)
+	if err != nil {
+		authRouter.Logger.Error(err.Error())
+	}
+
+	token, err := authRouter.TokenHandler.GenerateToken(ctx, userModel.Id, userModel.UserRole)
+	if err != nil {
+		return dtos.TokenPairResponse{}, fmt.Errorf("error happened while generating token for user with uuid %s: %w", userModel.Id, err)
+	}
+
+	refreshToken, err := authRouter.RefreshTokenHandler.Issue(ctx, userModel.Id)
+	if err != nil {
+		return dtos.TokenPairResponse{}, fmt.Errorf("error happened while issuing refresh token for user with uuid %s: %w", userModel.Id, err)
+	}
+
+	return dtos.TokenPairResponse{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// Saves time of login and event about it in one transaction
+func (authRouter *AuthRouter) recordLogin(ctx context.Context, userModel *entities.User, isNewUser bool) error {
+	loggedInAt := time.Now()
+
+	return authRouter.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := authRouter.UserRepository.UpdateLastLogin(ctx, userModel.Id, loggedInAt)
+		if err != nil {
+			return err
+		}
+
+		return authRouter.KafkaProducer.PublishUserEvent(ctx, services.NewUserEvent(services.UserEventLoggedIn, userModel.Id, loggedInAt,
+			services.UserLoggedInEventData{IsNewUser: isNewUser}))
+	})
+}
+
+// Creates user on first login. Concurrent login could create the same user first, then that user is returned
+// and invite code is left unused
+func (authRouter *AuthRouter) registerOnLogin(ctx context.Context, phoneNumber string, role *entities.Role, status string, inviteCode string) (*entities.User, bool, error) {
+	newUser := &entities.User{Id: uuid.New(), PhoneNumber: phoneNumber, UserRole: role.Name, Status: status}
+
+	err := authRouter.addUser(ctx, newUser, role, inviteCode)
+	if err == nil {
+		return newUser, true, nil
+	}
+
+	if !errors.Is(err, repositories.ErrUserAlreadyExists) {
+		return nil, false, err
+	}
+
+	existingUser, err := authRouter.UserRepository.Get(ctx, phoneNumber)
+	if err != nil {
+		return nil, false, fmt.Errorf("while retrieving user from database happened error: %w", err)
+	}
+
+	return existingUser, false, nil
+}
+
+// Adds new user and uses invite code checked by checkRegistrationPolicy, if registration needs it.
+// Both happen in one transaction with event about registration, so code isn't spent if user can't be added and vice versa
+func (authRouter *AuthRouter) addUser(ctx context.Context, newUser *entities.User, role *entities.Role, inviteCode string) error {
+	return authRouter.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		// Uses of code could run out since it was checked
+		if role.RegistrationPolicy != entities.RoleRegistrationOpen && newUser.Status == entities.UserStatusActive {
+			err := authRouter.InviteCodeService.Use(ctx, inviteCode, role.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := authRouter.UserRepository.Add(ctx, newUser)
+		if err != nil {
+			return err
+		}
+
+		return authRouter.KafkaProducer.PublishUserEvent(ctx, services.NewUserEvent(services.UserEventRegistered, newUser.Id, newUser.CreatedAt,
+			services.UserRegisteredEventData{PhoneNumber: newUser.PhoneNumber, Role: newUser.UserRole, Status: newUser.Status}))
+	})
+}
+
+// Decides status of new user by registration policy of role. Invite code is only checked here, it's used by addUser
+// once registration is confirmed. If user can't register with role, responds with error and returns false and result of responding
+func (authRouter *AuthRouter) checkRegistrationPolicy(context echo.Context, role *entities.Role, inviteCode string) (string, bool, error) {
+	if role.RegistrationPolicy == entities.RoleRegistrationOpen {
+		return entities.UserStatusActive, true, nil
+	}
+
+	if inviteCode == "" {
+		if role.RegistrationPolicy == entities.RoleRegistrationApproval {
+			return entities.UserStatusPendingApproval, true, nil
+		}
+
+		return "", false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invite code is required for this role", ErrorCode: dtos.ErrorCodeInviteCodeRequired})
+	}
+
+	isUsable, err := authRouter.InviteCodeService.IsUsable(context.Request().Context(), inviteCode, role.Name)
+	if err != nil {
+		authRouter.Logger.Error(err.Error())
+		return "", false, context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while checking invite code"})
+	}
+
+	if !isUsable {
+		authRouter.Logger.Warn(fmt.Sprintf("user sent invalid invite code for role %s", role.Name))
+		return "", false, respondInviteCodeInvalid(context)
+	}
+
+	return entities.UserStatusActive, true, nil
+}
+
+func respondInviteCodeInvalid(context echo.Context) error {
+	return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invite code is invalid, expired or used up", ErrorCode: dtos.ErrorCodeInviteCodeInvalid})
+}
+
+func respondInvalidPhoneNumber(context echo.Context, logger *zap.Logger, phoneNumber string, err error) error {
+	logger.Error(fmt.Errorf("user sent invalid phone number %s: %w", phoneNumber, err).Error())
+
+	if errors.Is(err, phone.ErrCountryNotAllowed) {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Phone numbers of this country are not supported", ErrorCode: dtos.ErrorCodePhoneCountryNotAllowed})
+	}
+
+	return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid phone number", ErrorCode: dtos.ErrorCodeInvalidPhoneNumber})
 }
@@ -0,0 +1,165 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/middlewares"
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Management of invite codes for registration with privileged roles, part of admin API
+type InviteCodeRouter struct {
+	Logger            *zap.Logger
+	InviteCodeService services.InviteCodeService
+	RoleService       services.RoleService
+}
+
+func NewInviteCodeRouter(logger *zap.Logger, inviteCodeService services.InviteCodeService, roleService services.RoleService) *InviteCodeRouter {
+	return &InviteCodeRouter{
+		Logger:            logger,
+		InviteCodeService: inviteCodeService,
+		RoleService:       roleService,
+	}
+}
+
+const defaultInviteCodeLifetimeHours = 7 * 24
+const maxInviteCodeLifetimeHours = 365 * 24
+const maxInviteCodeUses = 10000
+
+// ListInviteCodes godoc
+// @Title ListInviteCodes
+// @Summary Getting all invite codes (without codes themselves)
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {array} dtos.InviteCodeDto "Invite codes, newest first"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/invite-codes [get]
+func (inviteCodeRouter *InviteCodeRouter) ListInviteCodes(context echo.Context) error {
+	inviteCodes, err := inviteCodeRouter.InviteCodeService.GetAll(context.Request().Context())
+	if err != nil {
+		inviteCodeRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving invite codes"})
+	}
+
+	response := []dtos.InviteCodeDto{}
+	for _, inviteCode := range inviteCodes {
+		response = append(response, buildInviteCodeDto(inviteCode))
+	}
+
+	return context.JSON(http.StatusOK, response)
+}
+
+// CreateInviteCode godoc
+// @Title CreateInviteCode
+// @Summary Creating invite code for registration with role
+// @Description Code is returned only in this response, only its hash is stored
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.CreateInviteCodeRequest true "Dto with role, limit of uses and lifetime of code"
+// @Success 201 {object} dtos.CreateInviteCodeResponse "Created code"
+// @Failure 400 {object} dtos.ErrorDto "Invalid limit of uses or lifetime"
+// @Failure 400 {object} dtos.ErrorDto "Role doesn't exist (error_code: role_not_found)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/invite-codes [post]
+func (inviteCodeRouter *InviteCodeRouter) CreateInviteCode(context echo.Context) error {
+	request := dtos.CreateInviteCodeRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	if _, exists := inviteCodeRouter.RoleService.Get(request.Role); !exists {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Role doesn't exist", ErrorCode: dtos.ErrorCodeRoleNotFound})
+	}
+
+	if request.MaxUses == 0 {
+		request.MaxUses = 1
+	}
+
+	if request.MaxUses < 1 || request.MaxUses > maxInviteCodeUses {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Limit of uses must be from 1 to %d", maxInviteCodeUses)})
+	}
+
+	lifetimeHours := defaultInviteCodeLifetimeHours
+	if request.ExpiresInHours != nil {
+		lifetimeHours = *request.ExpiresInHours
+	}
+
+	if lifetimeHours < 0 || lifetimeHours > maxInviteCodeLifetimeHours {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Lifetime of code must be from 0 to %d hours", maxInviteCodeLifetimeHours)})
+	}
+
+	createdBy := uuid.Nil
+	if claims := middlewares.GetClaims(context); claims != nil {
+		createdBy = claims.UserId
+	}
+
+	code, inviteCode, err := inviteCodeRouter.InviteCodeService.Create(context.Request().Context(), request.Role, request.MaxUses, time.Duration(lifetimeHours)*time.Hour, createdBy)
+	if err != nil {
+		inviteCodeRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while creating invite code"})
+	}
+
+	logAdminAction(inviteCodeRouter.Logger, context, fmt.Sprintf("invite code %s for role %s was created", inviteCode.Id, inviteCode.RoleName))
+	return context.JSON(http.StatusCreated, dtos.CreateInviteCodeResponse{Code: code, InviteCode: buildInviteCodeDto(inviteCode)})
+}
+
+// RevokeInviteCode godoc
+// @Title RevokeInviteCode
+// @Summary Revoking invite code, so it can't be used anymore
+// @Tags Admin
+// @Security JwtBearer
+// @Param id path string true "Id of invite code"
+// @Success 204 "Code is revoked"
+// @Failure 400 {object} dtos.ErrorDto "Invalid id of code"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "Code doesn't exist or is already revoked"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/invite-codes/{id} [delete]
+func (inviteCodeRouter *InviteCodeRouter) RevokeInviteCode(context echo.Context) error {
+	id, err := uuid.Parse(context.Param("id"))
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid id of invite code"})
+	}
+
+	isRevoked, err := inviteCodeRouter.InviteCodeService.Revoke(context.Request().Context(), id)
+	if err != nil {
+		inviteCodeRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while revoking invite code"})
+	}
+
+	if !isRevoked {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "Invite code doesn't exist or is already revoked"})
+	}
+
+	logAdminAction(inviteCodeRouter.Logger, context, fmt.Sprintf("invite code %s was revoked", id))
+	return context.NoContent(http.StatusNoContent)
+}
+
+func buildInviteCodeDto(inviteCode *entities.InviteCode) dtos.InviteCodeDto {
+	return dtos.InviteCodeDto{
+		Id:        inviteCode.Id.String(),
+		Role:      inviteCode.RoleName,
+		MaxUses:   inviteCode.MaxUses,
+		UsedCount: inviteCode.UsedCount,
+		CreatedBy: inviteCode.CreatedBy.String(),
+		CreatedAt: inviteCode.CreatedAt,
+		ExpiresAt: inviteCode.ExpiresAt,
+		RevokedAt: inviteCode.RevokedAt,
+	}
+}
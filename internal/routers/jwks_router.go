@@ -0,0 +1,34 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type JwksRouter struct {
+	Logger       *zap.Logger
+	TokenHandler services.TokenHandler
+}
+
+func NewJwksRouter(logger *zap.Logger, tokenHandler services.TokenHandler) *JwksRouter {
+	return &JwksRouter{
+		Logger:       logger,
+		TokenHandler: tokenHandler,
+	}
+}
+
+// GetJwks godoc
+// @Title GetJwks
+// @Summary Public keys for verifying tokens locally
+// @Description Returns JSON Web Key Set (RFC 7517) with public keys of asymmetric algorithms. For HS256 set is empty - such tokens can be checked only through validate-token
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} services.JsonWebKeySet "Set of public keys"
+// @Router /.well-known/jwks.json [get]
+func (jwksRouter *JwksRouter) GetJwks(context echo.Context) error {
+	context.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return context.JSON(http.StatusOK, jwksRouter.TokenHandler.GetJsonWebKeySet())
+}
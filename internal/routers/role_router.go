@@ -0,0 +1,245 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Management of role definitions, part of admin API
+type RoleRouter struct {
+	Logger      *zap.Logger
+	RoleService services.RoleService
+}
+
+func NewRoleRouter(logger *zap.Logger, roleService services.RoleService) *RoleRouter {
+	return &RoleRouter{
+		Logger:      logger,
+		RoleService: roleService,
+	}
+}
+
+// Name of role fits users.user_role column
+var roleNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,24}$`)
+
+// Permission is lowercase identifier like "trainings:write"
+var permissionRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,99}$`)
+
+const maxRoleDescriptionLength = 256
+
+var registrationPolicies = []string{entities.RoleRegistrationOpen, entities.RoleRegistrationInviteCode, entities.RoleRegistrationApproval}
+
+// ListRoles godoc
+// @Title ListRoles
+// @Summary All roles with their permissions
+// @Tags Admin
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {array} dtos.RoleDto "Roles sorted by name"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Router /api/v1/admin/roles [get]
+func (roleRouter *RoleRouter) ListRoles(context echo.Context) error {
+	roles := roleRouter.RoleService.GetAll()
+
+	response := make([]dtos.RoleDto, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, buildRoleDto(role))
+	}
+
+	return context.JSON(http.StatusOK, response)
+}
+
+// CreateRole godoc
+// @Title CreateRole
+// @Summary Creating new role
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.CreateRoleRequest true "Dto with definition of role"
+// @Success 201 {object} dtos.RoleDto "Created role"
+// @Failure 400 {object} dtos.ErrorDto "Invalid name, description, permissions or registration policy"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 409 {object} dtos.ErrorDto "Role already exists (error_code: role_exists)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/roles [post]
+func (roleRouter *RoleRouter) CreateRole(context echo.Context) error {
+	request := dtos.CreateRoleRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	if !roleNameRegex.MatchString(request.Name) {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Name of role must start with letter and consist of up to 25 letters, digits, '_' or '-'"})
+	}
+
+	role, isValid, err := roleRouter.buildRole(context, request.Name, request.Description, request.Permissions, request.IsSelfAssignable, request.RegistrationPolicy)
+	if !isValid {
+		return err
+	}
+
+	err = roleRouter.RoleService.Add(context.Request().Context(), role)
+	if errors.Is(err, repositories.ErrRoleExists) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Role already exists", ErrorCode: dtos.ErrorCodeRoleExists})
+	}
+
+	if err != nil {
+		roleRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while creating role"})
+	}
+
+	logAdminAction(roleRouter.Logger, context, fmt.Sprintf("role %s was created", role.Name))
+	return context.JSON(http.StatusCreated, buildRoleDto(role))
+}
+
+// UpdateRole godoc
+// @Title UpdateRole
+// @Summary Replacing definition of role
+// @Description Changed permissions get into tokens issued after change
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param name path string true "Name of role"
+// @Param request body dtos.UpdateRoleRequest true "Dto with new definition of role"
+// @Success 200 {object} dtos.RoleDto "Changed role"
+// @Failure 400 {object} dtos.ErrorDto "Invalid description, permissions or registration policy"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "Role doesn't exist"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/roles/{name} [put]
+func (roleRouter *RoleRouter) UpdateRole(context echo.Context) error {
+	request := dtos.UpdateRoleRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	role, isValid, err := roleRouter.buildRole(context, context.Param("name"), request.Description, request.Permissions, request.IsSelfAssignable, request.RegistrationPolicy)
+	if !isValid {
+		return err
+	}
+
+	isUpdated, err := roleRouter.RoleService.Update(context.Request().Context(), role)
+	if err != nil {
+		roleRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while updating role"})
+	}
+
+	if !isUpdated {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "Role doesn't exist"})
+	}
+
+	// Cached role has creation time, which isn't known here
+	updatedRole, exists := roleRouter.RoleService.Get(role.Name)
+	if !exists {
+		updatedRole = role
+	}
+
+	logAdminAction(roleRouter.Logger, context, fmt.Sprintf("role %s was updated", role.Name))
+	return context.JSON(http.StatusOK, buildRoleDto(updatedRole))
+}
+
+// DeleteRole godoc
+// @Title DeleteRole
+// @Summary Deleting role
+// @Description Role which is primary role of some user can't be deleted. Users who have role as additional one lose it
+// @Tags Admin
+// @Security JwtBearer
+// @Param name path string true "Name of role"
+// @Success 204 "Role is deleted"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 403 {object} dtos.ErrorDto "Token doesn't belong to admin (error_code: forbidden)"
+// @Failure 404 {object} dtos.ErrorDto "Role doesn't exist"
+// @Failure 409 {object} dtos.ErrorDto "Role is primary role of some users (error_code: role_in_use) or built-in (role_built_in)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/admin/roles/{name} [delete]
+func (roleRouter *RoleRouter) DeleteRole(context echo.Context) error {
+	roleName := context.Param("name")
+
+	isDeleted, err := roleRouter.RoleService.Delete(context.Request().Context(), roleName)
+	if errors.Is(err, services.ErrRoleBuiltIn) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Built-in role can't be deleted", ErrorCode: dtos.ErrorCodeRoleBuiltIn})
+	}
+
+	if errors.Is(err, repositories.ErrRoleInUse) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Role is primary role of some users", ErrorCode: dtos.ErrorCodeRoleInUse})
+	}
+
+	if err != nil {
+		roleRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while deleting role"})
+	}
+
+	if !isDeleted {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "Role doesn't exist"})
+	}
+
+	logAdminAction(roleRouter.Logger, context, fmt.Sprintf("role %s was deleted", roleName))
+	return context.NoContent(http.StatusNoContent)
+}
+
+// Validates fields of role. If they are invalid, responds with error and returns false and result of responding
+func (roleRouter *RoleRouter) buildRole(context echo.Context, name string, description string, permissions []string,
+	isSelfAssignable bool, registrationPolicy string) (*entities.Role, bool, error) {
+	if registrationPolicy == "" {
+		registrationPolicy = entities.RoleRegistrationOpen
+	}
+
+	if !slices.Contains(registrationPolicies, registrationPolicy) {
+		return nil, false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Registration policy must be one of: " + strings.Join(registrationPolicies, ", ")})
+	}
+
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxRoleDescriptionLength {
+		return nil, false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Description must be not longer than %d symbols", maxRoleDescriptionLength)})
+	}
+
+	uniquePermissions := []string{}
+	for _, permission := range permissions {
+		if !permissionRegex.MatchString(permission) {
+			return nil, false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Invalid permission: %q", permission)})
+		}
+
+		if !slices.Contains(uniquePermissions, permission) {
+			uniquePermissions = append(uniquePermissions, permission)
+		}
+	}
+
+	slices.Sort(uniquePermissions)
+
+	return &entities.Role{
+		Name:               name,
+		Description:        description,
+		Permissions:        uniquePermissions,
+		IsSelfAssignable:   isSelfAssignable,
+		RegistrationPolicy: registrationPolicy,
+	}, true, nil
+}
+
+func buildRoleDto(role *entities.Role) dtos.RoleDto {
+	return dtos.RoleDto{
+		Name:               role.Name,
+		Description:        role.Description,
+		Permissions:        role.Permissions,
+		IsSelfAssignable:   role.IsSelfAssignable,
+		RegistrationPolicy: role.RegistrationPolicy,
+		CreatedAt:          role.CreatedAt,
+		UpdatedAt:          role.UpdatedAt,
+	}
+}
@@ -0,0 +1,137 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/middlewares"
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Sending and checking of sms codes with lockouts and rate limits, shared by routers which confirm phone numbers
+type SmsVerifier struct {
+	Logger        *zap.Logger
+	SmsCodeSender services.SmsCodeSender
+	SmsStorage    services.SmsStorage
+	RateLimiter   services.RateLimiter
+	RateLimits    services.RateLimitConfig
+}
+
+func NewSmsVerifier(logger *zap.Logger,
+	smsCodeSender services.SmsCodeSender,
+	smsStorage services.SmsStorage,
+	rateLimiter services.RateLimiter,
+	rateLimits services.RateLimitConfig) *SmsVerifier {
+
+	return &SmsVerifier{
+		Logger:        logger,
+		SmsCodeSender: smsCodeSender,
+		SmsStorage:    smsStorage,
+		RateLimiter:   rateLimiter,
+		RateLimits:    rateLimits}
+}
+
+// Checks lockouts and rate limits of all phone numbers and sends new sms code to each of them.
+// Nothing is sent and counted unless every number is allowed, so request rejected because of one number
+// doesn't put others into cooldown
+func (verifier *SmsVerifier) SendCode(context echo.Context, phoneNumbers ...string) error {
+	for _, phoneNumber := range phoneNumbers {
+		lockedUntil, err := verifier.SmsStorage.LockedUntil(phoneNumber)
+		if err != nil {
+			verifier.Logger.Error(fmt.Errorf("while checking lockout of phone number happened error: %w", err).Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+		}
+
+		if !lockedUntil.IsZero() {
+			return respondSmsLocked(context, lockedUntil)
+		}
+	}
+
+	// Cooldowns and limits are checked by one call, so request rejected by any of them isn't counted by others
+	rules := []services.RateLimitRule{}
+	cooldownKeys := []string{}
+	for _, phoneNumber := range phoneNumbers {
+		cooldownRule := services.RateLimitRule{
+			Key:    "sms-cooldown:phone:" + phoneNumber,
+			Limit:  1,
+			Window: time.Duration(verifier.RateLimits.SmsResendCooldownSeconds) * time.Second,
+		}
+
+		rules = append(rules, cooldownRule)
+		rules = append(rules, verifier.smsRateLimitRules(phoneNumber, context.RealIP())...)
+		cooldownKeys = append(cooldownKeys, cooldownRule.Key)
+	}
+
+	rateLimitResult, err := verifier.RateLimiter.Allow(rules...)
+	if err != nil {
+		verifier.Logger.Error(fmt.Errorf("while checking sms rate limits happened error: %w", err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	// Client is told about cooldown only when waiting for it is enough
+	isBlockedByCooldown := !slices.ContainsFunc(rateLimitResult.BlockedKeys, func(key string) bool { return !slices.Contains(cooldownKeys, key) })
+	if !rateLimitResult.IsAllowed && isBlockedByCooldown {
+		return middlewares.RespondTooManyRequests(context, rateLimitResult.RetryAfter, "SMS code was sent recently, wait before requesting new one", dtos.ErrorCodeSmsResendCooldown)
+	}
+
+	if !rateLimitResult.IsAllowed {
+		verifier.Logger.Warn(fmt.Sprintf("sms rate limit exceeded for phone numbers %v from ip %s", phoneNumbers, context.RealIP()))
+		return middlewares.RespondTooManyRequests(context, rateLimitResult.RetryAfter, "Too many SMS requests, try again later", dtos.ErrorCodeRateLimited)
+	}
+
+	for _, phoneNumber := range phoneNumbers {
+		err = verifier.SmsCodeSender.Send(context.Request().Context(), phoneNumber)
+		if err != nil {
+			verifier.Logger.Error(fmt.Errorf("while sending sms code to %s happened error: %w", phoneNumber, err).Error())
+			return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while sending sms to this phone number"})
+		}
+	}
+
+	return context.NoContent(200)
+}
+
+// Verifies sms code. If code is not accepted, responds with error and returns false and result of responding
+func (verifier *SmsVerifier) CheckCode(context echo.Context, phoneNumber string, smsCode string) (bool, error) {
+	verificationResult, err := verifier.SmsStorage.Verify(phoneNumber, smsCode)
+	if err != nil {
+		verifier.Logger.Error(fmt.Errorf("while verifying sms code happened error: %w", err).Error())
+		return false, context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened internal error"})
+	}
+
+	switch verificationResult.Status {
+	case services.SmsCodeNotRequested:
+		verifier.Logger.Error(fmt.Errorf("for user with phone number %s wasn't produced any sms code", phoneNumber).Error())
+		return false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Sms code wasn't requested", ErrorCode: dtos.ErrorCodeSmsCodeNotRequested})
+	case services.SmsCodeInvalid:
+		verifier.Logger.Error(fmt.Errorf("invalid sms code for user with phone number %s, attempts left: %d", phoneNumber, verificationResult.AttemptsLeft).Error())
+		return false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Invalid SMS code, attempts left: %d", verificationResult.AttemptsLeft), ErrorCode: dtos.ErrorCodeSmsCodeInvalid})
+	case services.SmsCodeLocked:
+		verifier.Logger.Warn(fmt.Sprintf("phone number %s is locked after too many invalid sms codes", phoneNumber))
+		return false, respondSmsLocked(context, verificationResult.LockedUntil)
+	}
+
+	return true, nil
+}
+
+// Hourly and daily limits of sms for phone number and client ip. Rules of ip are repeated for every phone number,
+// so every sent sms is counted
+func (verifier *SmsVerifier) smsRateLimitRules(phoneNumber string, ip string) []services.RateLimitRule {
+	day := 24 * time.Hour
+
+	return []services.RateLimitRule{
+		{Key: "sms-hour:phone:" + phoneNumber, Limit: verifier.RateLimits.SmsPerPhonePerHour, Window: time.Hour},
+		{Key: "sms-day:phone:" + phoneNumber, Limit: verifier.RateLimits.SmsPerPhonePerDay, Window: day},
+		{Key: "sms-hour:ip:" + ip, Limit: verifier.RateLimits.SmsPerIpPerHour, Window: time.Hour},
+		{Key: "sms-day:ip:" + ip, Limit: verifier.RateLimits.SmsPerIpPerDay, Window: day},
+	}
+}
+
+// Responds that phone number is locked after too many invalid sms codes
+func respondSmsLocked(context echo.Context, lockedUntil time.Time) error {
+	return middlewares.RespondTooManyRequests(context, time.Until(lockedUntil), "Too many invalid SMS codes, try again later", dtos.ErrorCodeSmsCodeLocked)
+}
@@ -0,0 +1,413 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/middlewares"
+	"github.com/WebChads/AuthService/internal/models/dtos"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/phone"
+	"github.com/WebChads/AuthService/internal/services"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type UserRouter struct {
+	Logger              *zap.Logger
+	UserRepository      repositories.UserRepository
+	PhoneParser         phone.Parser
+	SmsVerifier         *SmsVerifier
+	TokenHandler        services.TokenHandler
+	RefreshTokenHandler services.RefreshTokenHandler
+	KafkaProducer       services.KafkaProducer
+	AccountManager      *AccountManager
+	TransactionRunner   repositories.TransactionRunner
+	RoleService         services.RoleService
+}
+
+func NewUserRouter(logger *zap.Logger,
+	userRepository repositories.UserRepository,
+	phoneParser phone.Parser,
+	smsVerifier *SmsVerifier,
+	tokenHandler services.TokenHandler,
+	refreshTokenHandler services.RefreshTokenHandler,
+	kafkaProducer services.KafkaProducer,
+	accountManager *AccountManager,
+	transactionRunner repositories.TransactionRunner,
+	roleService services.RoleService) *UserRouter {
+
+	return &UserRouter{
+		Logger:              logger,
+		UserRepository:      userRepository,
+		PhoneParser:         phoneParser,
+		SmsVerifier:         smsVerifier,
+		TokenHandler:        tokenHandler,
+		RefreshTokenHandler: refreshTokenHandler,
+		KafkaProducer:       kafkaProducer,
+		AccountManager:      accountManager,
+		TransactionRunner:   transactionRunner,
+		RoleService:         roleService,
+	}
+}
+
+const maxDisplayNameLength = 64
+
+// GetMe godoc
+// @Title GetMe
+// @Summary Profile of current user
+// @Tags Users
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {object} dtos.UserProfileResponse "Profile of user who owns token"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist anymore"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me [get]
+func (userRouter *UserRouter) GetMe(context echo.Context) error {
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	return context.JSON(http.StatusOK, buildUserProfileResponse(userModel))
+}
+
+// UpdateMe godoc
+// @Title UpdateMe
+// @Summary Changing profile of current user
+// @Description Only passed fields are changed
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.UpdateProfileRequest true "Dto with changed fields of profile"
+// @Success 200 {object} dtos.UserProfileResponse "Changed profile"
+// @Failure 400 {object} dtos.ErrorDto "Invalid display name"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist anymore"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me [patch]
+func (userRouter *UserRouter) UpdateMe(context echo.Context) error {
+	request := dtos.UpdateProfileRequest{}
+	err := context.Bind(&request)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid request body"})
+	}
+
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	if request.DisplayName != nil {
+		displayName := strings.TrimSpace(*request.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: fmt.Sprintf("Display name must be not longer than %d symbols", maxDisplayNameLength)})
+		}
+
+		userModel.DisplayName = displayName
+	}
+
+	// Role and status of loaded user can be already changed by admin, so only profile is saved
+	err = userRouter.UserRepository.UpdateProfile(context.Request().Context(), userModel)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while updating user"})
+	}
+
+	return context.JSON(http.StatusOK, buildUserProfileResponse(userModel))
+}
+
+// DeleteMe godoc
+// @Title DeleteMe
+// @Summary Deleting account of current user
+// @Description User is marked deleted and all its sessions are revoked, deleted user can't log in. After grace period (users.deletion_grace_period_days) all data of user is purged.
+// @Description Until then user can restore account by login with restore_account flag
+// @Tags Users
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {object} dtos.DeleteAccountResponse "Account is deleted, giving moment of purge"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist anymore"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me [delete]
+func (userRouter *UserRouter) DeleteMe(context echo.Context) error {
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	purgeAt, err := userRouter.AccountManager.DeleteAccount(context.Request().Context(), userModel.Id, true)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while deleting user"})
+	}
+
+	return context.JSON(http.StatusOK, dtos.DeleteAccountResponse{PurgeAt: purgeAt})
+}
+
+// ExportMe godoc
+// @Title ExportMe
+// @Summary Exporting all data AuthService holds on current user
+// @Tags Users
+// @Produce json
+// @Security JwtBearer
+// @Success 200 {object} dtos.UserDataExportResponse "Profile, additional roles and sessions of user"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 404 {object} dtos.ErrorDto "User doesn't exist anymore"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me/export [get]
+func (userRouter *UserRouter) ExportMe(context echo.Context) error {
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	additionalRoles, err := userRouter.RoleService.GetUserRoles(context.Request().Context(), userModel.Id)
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving roles of user"})
+	}
+
+	refreshTokens, err := userRouter.RefreshTokenHandler.GetAll(context.Request().Context(), userModel.Id)
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving sessions of user"})
+	}
+
+	sessions := make([]dtos.SessionExportDto, 0, len(refreshTokens))
+	for _, refreshToken := range refreshTokens {
+		sessions = append(sessions, dtos.SessionExportDto{
+			Id:        refreshToken.Id.String(),
+			FamilyId:  refreshToken.FamilyId.String(),
+			CreatedAt: refreshToken.CreatedAt,
+			ExpiresAt: refreshToken.ExpiresAt,
+			RevokedAt: refreshToken.RevokedAt,
+		})
+	}
+
+	// Export contains personal data, so it mustn't be kept by proxies
+	context.Response().Header().Set("Cache-Control", "no-store")
+	context.Response().Header().Set("Content-Disposition", `attachment; filename="user-data.json"`)
+
+	return context.JSON(http.StatusOK, dtos.UserDataExportResponse{
+		ExportedAt:      time.Now(),
+		Profile:         buildUserProfileResponse(userModel),
+		AdditionalRoles: additionalRoles,
+		Sessions:        sessions,
+	})
+}
+
+// StartPhoneChange godoc
+// @Title StartPhoneChange
+// @Summary Starting change of phone number: sending sms codes to current and new phone numbers
+// @Description Both codes are required to complete change, so access token alone is not enough to move account to another number.
+// @Description Lockouts and rate limits of both numbers are checked first, if any of them fails, no code is sent
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.StartPhoneChangeRequest true "Dto with new phone number"
+// @Success 200 "Successfully sent codes to current and new phone numbers"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number), country is not supported (phone_country_not_allowed) or number is the same (phone_number_unchanged)"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 409 {object} dtos.ErrorDto "Phone number belongs to another user (error_code: phone_number_taken)"
+// @Failure 429 {object} dtos.ErrorDto "Phone number is locked (error_code: sms_code_locked), SMS was sent recently (sms_resend_cooldown) or too many SMS requests (rate_limited)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me/phone/start [post]
+func (userRouter *UserRouter) StartPhoneChange(context echo.Context) error {
+	request := dtos.StartPhoneChangeRequest{}
+	context.Bind(&request)
+
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	newPhoneNumber, isAllowed, err := userRouter.checkNewPhoneNumber(context, userModel, request.NewPhoneNumber)
+	if !isAllowed {
+		return err
+	}
+
+	// Limits of both numbers are checked before any code is sent
+	return userRouter.SmsVerifier.SendCode(context, userModel.PhoneNumber, newPhoneNumber)
+}
+
+// CompletePhoneChange godoc
+// @Title CompletePhoneChange
+// @Summary Completing change of phone number: verifying sms codes sent to current and new phone numbers
+// @Description Request must be authenticated by token of account and confirmed by codes from current and new phone numbers.
+// @Description Code of current number is checked first and is spent even if code of new number is wrong, then change must be started again.
+// @Description All other sessions of user are revoked, new pair of tokens is returned
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security JwtBearer
+// @Param request body dtos.CompletePhoneChangeRequest true "Dto with new phone number and SMS codes of current and new phone numbers"
+// @Success 200 {object} dtos.TokenPairResponse "Phone number is changed, giving new access and refresh tokens"
+// @Failure 400 {object} dtos.ErrorDto "Invalid phone number (error_code: invalid_phone_number), country is not supported (phone_country_not_allowed) or number is the same (phone_number_unchanged)"
+// @Failure 400 {object} dtos.ErrorDto "Invalid SMS code format"
+// @Failure 400 {object} dtos.ErrorDto "Invalid SMS code"
+// @Failure 401 {object} dtos.ErrorDto "Token is missing or invalid"
+// @Failure 409 {object} dtos.ErrorDto "Phone number belongs to another user (error_code: phone_number_taken)"
+// @Failure 429 {object} dtos.ErrorDto "Too many invalid codes, phone number is locked (error_code: sms_code_locked) or too many attempts from ip (rate_limited)"
+// @Failure 500 {object} dtos.ErrorDto "Happened internal error"
+// @Router /api/v1/users/me/phone/complete [post]
+func (userRouter *UserRouter) CompletePhoneChange(context echo.Context) error {
+	request := dtos.CompletePhoneChangeRequest{}
+	context.Bind(&request)
+
+	userModel, err := userRouter.getCurrentUser(context)
+	if userModel == nil {
+		return err
+	}
+
+	newPhoneNumber, isAllowed, err := userRouter.checkNewPhoneNumber(context, userModel, request.NewPhoneNumber)
+	if !isAllowed {
+		return err
+	}
+
+	if !smsCodeRegex.MatchString(request.SmsCode) || !smsCodeRegex.MatchString(request.CurrentSmsCode) {
+		userRouter.Logger.Error(fmt.Errorf("user sent invalid sms code format: %s, %s", request.SmsCode, request.CurrentSmsCode).Error())
+		return context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "Invalid SMS code format"})
+	}
+
+	// Access token can be stolen, so owner of account must also confirm that they still own current number
+	isAccepted, err := userRouter.SmsVerifier.CheckCode(context, userModel.PhoneNumber, request.CurrentSmsCode)
+	if !isAccepted {
+		return err
+	}
+
+	isAccepted, err = userRouter.SmsVerifier.CheckCode(context, newPhoneNumber, request.SmsCode)
+	if !isAccepted {
+		return err
+	}
+
+	ctx := context.Request().Context()
+
+	err = userRouter.changePhoneNumber(ctx, userModel, newPhoneNumber)
+	if errors.Is(err, repositories.ErrPhoneNumberTaken) {
+		return context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Phone number belongs to another user", ErrorCode: dtos.ErrorCodePhoneNumberTaken})
+	}
+
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while updating user"})
+	}
+
+	// Sessions could be opened by previous owner of old SIM card, so all of them are closed
+	err = userRouter.AccountManager.RevokeAllSessions(ctx, userModel.Id)
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while revoking sessions of user"})
+	}
+
+	token, err := userRouter.TokenHandler.GenerateToken(ctx, userModel.Id, userModel.UserRole)
+	if err != nil {
+		userRouter.Logger.Error(fmt.Errorf("error happened while generating token for user with uuid %s: %w", userModel.Id, err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while generating token for user"})
+	}
+
+	refreshToken, err := userRouter.RefreshTokenHandler.Issue(ctx, userModel.Id)
+	if err != nil {
+		userRouter.Logger.Error(fmt.Errorf("error happened while issuing refresh token for user with uuid %s: %w", userModel.Id, err).Error())
+		return context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while generating token for user"})
+	}
+
+	return context.JSON(http.StatusOK, dtos.TokenPairResponse{Token: token, RefreshToken: refreshToken})
+}
+
+// Event is saved together with new number, so it's sent if and only if number is changed
+func (userRouter *UserRouter) changePhoneNumber(ctx context.Context, userModel *entities.User, newPhoneNumber string) error {
+	return userRouter.TransactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		err := userRouter.UserRepository.UpdatePhoneNumber(ctx, userModel.Id, newPhoneNumber)
+		if err != nil {
+			return err
+		}
+
+		return userRouter.KafkaProducer.PublishUserEvent(ctx, services.NewUserEvent(services.UserEventPhoneChanged, userModel.Id, time.Now(),
+			services.PhoneChangedEventData{OldPhoneNumber: userModel.PhoneNumber, NewPhoneNumber: newPhoneNumber}))
+	})
+}
+
+// Normalizes new phone number and checks that it differs from current one and is free.
+// If number can't be used, responds with error and returns false and result of responding
+func (userRouter *UserRouter) checkNewPhoneNumber(context echo.Context, userModel *entities.User, phoneNumber string) (string, bool, error) {
+	newPhoneNumber, err := userRouter.PhoneParser.Normalize(phoneNumber)
+	if err != nil {
+		return "", false, respondInvalidPhoneNumber(context, userRouter.Logger, phoneNumber, err)
+	}
+
+	if newPhoneNumber == userModel.PhoneNumber {
+		return "", false, context.JSON(http.StatusBadRequest, dtos.ErrorDto{ErrorMessage: "New phone number is the same as current one", ErrorCode: dtos.ErrorCodePhoneNumberUnchanged})
+	}
+
+	amountOfUsers, err := userRouter.UserRepository.Count(context.Request().Context(), newPhoneNumber)
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return "", false, context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
+	}
+
+	if amountOfUsers != 0 {
+		return "", false, context.JSON(http.StatusConflict, dtos.ErrorDto{ErrorMessage: "Phone number belongs to another user", ErrorCode: dtos.ErrorCodePhoneNumberTaken})
+	}
+
+	return newPhoneNumber, true, nil
+}
+
+// Returns user who owns token of request. If there is no such user, responds with error and returns nil and result of responding
+func (userRouter *UserRouter) getCurrentUser(context echo.Context) (*entities.User, error) {
+	claims := middlewares.GetClaims(context)
+	if claims == nil {
+		return nil, context.JSON(http.StatusUnauthorized, dtos.ErrorDto{ErrorMessage: "Authorization header with bearer token is required"})
+	}
+
+	userModel, err := userRouter.UserRepository.GetById(context.Request().Context(), claims.UserId)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	if err != nil {
+		userRouter.Logger.Error(err.Error())
+		return nil, context.JSON(http.StatusInternalServerError, dtos.ErrorDto{ErrorMessage: "Happened error while retrieving user from database"})
+	}
+
+	// Token of deleted user can be still cached as not revoked on other replica for a while
+	if userModel.Status == entities.UserStatusDeleted {
+		return nil, context.JSON(http.StatusNotFound, dtos.ErrorDto{ErrorMessage: "User doesn't exist"})
+	}
+
+	return userModel, nil
+}
+
+func buildUserProfileResponse(userModel *entities.User) dtos.UserProfileResponse {
+	return dtos.UserProfileResponse{
+		Id:          userModel.Id.String(),
+		PhoneNumber: userModel.PhoneNumber,
+		Role:        userModel.UserRole,
+		DisplayName: userModel.DisplayName,
+		Status:      userModel.Status,
+		CreatedAt:   userModel.CreatedAt,
+		UpdatedAt:   userModel.UpdatedAt,
+		LastLoginAt: userModel.LastLoginAt,
+	}
+}
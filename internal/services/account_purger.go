@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"go.uber.org/zap"
+)
+
+// Purges users whose deletion grace period is over. Refresh tokens of user are deleted by cascade
+type AccountPurger struct {
+	logger      *zap.Logger
+	repository  repositories.UserRepository
+	gracePeriod time.Duration
+}
+
+func NewAccountPurger(repository repositories.UserRepository, config UsersConfig, logger *zap.Logger) *AccountPurger {
+	return &AccountPurger{
+		logger:      logger,
+		repository:  repository,
+		gracePeriod: config.DeletionGracePeriod(),
+	}
+}
+
+// Purging is idempotent, so it's safe to run it on every replica
+func (purger *AccountPurger) StartPurging() {
+	ctx := context.Background()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purgedIds, err := purger.repository.PurgeDeleted(ctx, time.Now().Add(-purger.gracePeriod))
+		if err != nil {
+			purger.logger.Error("while purging deleted users happened error", zap.Error(err))
+			continue
+		}
+
+		for _, userId := range purgedIds {
+			purger.logger.Info("deleted user was purged", zap.String("user_id", userId.String()))
+		}
+	}
+}
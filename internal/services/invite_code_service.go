@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+var ErrInviteCodeInvalid = errors.New("invite code is invalid, expired or used up")
+
+type InviteCodeService interface {
+	// Returns plain code, which is not stored anywhere and can't be shown again.
+	// Zero lifetime means code doesn't expire
+	Create(ctx context.Context, roleName string, maxUses int, lifetime time.Duration, createdBy uuid.UUID) (string, *entities.InviteCode, error)
+
+	GetAll(ctx context.Context) ([]*entities.InviteCode, error)
+
+	// Returns false if code does not exists or already revoked
+	Revoke(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// Checks code without using it
+	IsUsable(ctx context.Context, code string, roleName string) (bool, error)
+
+	// Returns ErrInviteCodeInvalid if code can't be used for role
+	Use(ctx context.Context, code string, roleName string) error
+}
+
+// Symbols which can't be confused with each other when code is retyped (no 0/O, 1/I)
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const inviteCodeGroups = 3
+const inviteCodeGroupLength = 4
+
+type DbInviteCodeService struct {
+	repository repositories.InviteCodeRepository
+}
+
+func NewInviteCodeService(repository repositories.InviteCodeRepository) *DbInviteCodeService {
+	return &DbInviteCodeService{repository: repository}
+}
+
+func (service *DbInviteCodeService) Create(ctx context.Context, roleName string, maxUses int, lifetime time.Duration, createdBy uuid.UUID) (string, *entities.InviteCode, error) {
+	code, err := generateInviteCode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	inviteCode := &entities.InviteCode{
+		Id:        uuid.New(),
+		CodeHash:  hashInviteCode(code),
+		RoleName:  roleName,
+		MaxUses:   maxUses,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+
+	if lifetime > 0 {
+		expiresAt := now.Add(lifetime)
+		inviteCode.ExpiresAt = &expiresAt
+	}
+
+	err = service.repository.Add(ctx, inviteCode)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return code, inviteCode, nil
+}
+
+func (service *DbInviteCodeService) GetAll(ctx context.Context) ([]*entities.InviteCode, error) {
+	return service.repository.GetAll(ctx)
+}
+
+func (service *DbInviteCodeService) Revoke(ctx context.Context, id uuid.UUID) (bool, error) {
+	return service.repository.Revoke(ctx, id, time.Now())
+}
+
+func (service *DbInviteCodeService) IsUsable(ctx context.Context, code string, roleName string) (bool, error) {
+	inviteCode, err := service.repository.GetUsable(ctx, hashInviteCode(code), roleName)
+	if err != nil {
+		return false, err
+	}
+
+	return inviteCode != nil, nil
+}
+
+func (service *DbInviteCodeService) Use(ctx context.Context, code string, roleName string) error {
+	isUsed, err := service.repository.Use(ctx, hashInviteCode(code), roleName)
+	if err != nil {
+		return err
+	}
+
+	if !isUsed {
+		return ErrInviteCodeInvalid
+	}
+
+	return nil
+}
+
+// Code looks like "ABCD-EFGH-JKLM"
+func generateInviteCode() (string, error) {
+	randomBytes := make([]byte, inviteCodeGroups*inviteCodeGroupLength)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, randomByte := range randomBytes {
+		if i > 0 && i%inviteCodeGroupLength == 0 {
+			code.WriteByte('-')
+		}
+
+		// Alphabet has 32 symbols, so every symbol is equally likely
+		code.WriteByte(inviteCodeAlphabet[int(randomByte)%len(inviteCodeAlphabet)])
+	}
+
+	return code.String(), nil
+}
+
+// Code is hashed in normalized form, so it can be typed in lower case and without dashes
+func hashInviteCode(code string) string {
+	normalizedCode := strings.ToUpper(code)
+	normalizedCode = strings.ReplaceAll(normalizedCode, "-", "")
+	normalizedCode = strings.ReplaceAll(normalizedCode, " ", "")
+
+	hash := sha256.Sum256([]byte(normalizedCode))
+	return hex.EncodeToString(hash[:])
+}
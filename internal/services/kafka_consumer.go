@@ -5,16 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 	"time"
 
+	"github.com/WebChads/AuthService/internal/phone"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"go.uber.org/zap"
 )
 
 type KafkaConsumer interface {
 	Start()
-	GetSmsCode(phoneNumber string) (string, bool)
 }
 
 type confluentKafkaConsumer struct {
@@ -22,6 +21,11 @@ type confluentKafkaConsumer struct {
 
 	kafkaConsumer *kafka.Consumer
 	smsStorage    SmsStorage
+	phoneParser   phone.Parser
+	codeLength    int
+
+	topicPartitions        int
+	topicReplicationFactor int
 
 	isStarted bool
 }
@@ -32,7 +36,6 @@ type SmsCodeMessage struct {
 }
 
 var consumerTopicName = "sms-to-auth"
-var compiledPhoneNumberRegex *regexp.Regexp
 
 func (kafkaConsumer *confluentKafkaConsumer) Start() {
 	if kafkaConsumer.isStarted {
@@ -88,30 +91,38 @@ func (kafkaConsumer *confluentKafkaConsumer) Start() {
 			continue
 		}
 
-		kafkaConsumer.logger.Info(fmt.Sprintf("received sms code message for %s: %s", codeMessage.PhoneNumber, codeMessage.SmsCode))
+		kafkaConsumer.logger.Info(fmt.Sprintf("received sms code message for %s", codeMessage.PhoneNumber))
 
-		isPhoneNumberCorrect := compiledPhoneNumberRegex.Match([]byte(codeMessage.PhoneNumber))
-		if !isPhoneNumberCorrect {
-			kafkaConsumer.logger.Error(fmt.Errorf("user sent invalid phone number: %s", codeMessage.PhoneNumber).Error())
+		phoneNumber, err := kafkaConsumer.phoneParser.Normalize(codeMessage.PhoneNumber)
+		if err != nil {
+			kafkaConsumer.logger.Error(fmt.Errorf("received invalid phone number %s: %w", codeMessage.PhoneNumber, err).Error())
 			continue
 		}
 
-		if len(codeMessage.SmsCode) != 4 {
-			kafkaConsumer.logger.Error(fmt.Errorf("wrong format of sms code (must be 4 digits): %s", codeMessage.SmsCode).Error())
+		if !isSmsCodeOfLength(codeMessage.SmsCode, kafkaConsumer.codeLength) {
+			kafkaConsumer.logger.Error(fmt.Errorf("wrong format of sms code for %s (must be %d digits)", codeMessage.PhoneNumber, kafkaConsumer.codeLength).Error())
 			continue
 		}
 
-		kafkaConsumer.smsStorage.Set(codeMessage.PhoneNumber, codeMessage.SmsCode)
+		err = kafkaConsumer.smsStorage.Set(phoneNumber, codeMessage.SmsCode)
+		if err != nil {
+			kafkaConsumer.logger.Error("while saving sms code happened error", zap.Error(err))
+		}
 	}
 }
 
-func (kafkaConsumer *confluentKafkaConsumer) GetSmsCode(phoneNumber string) (string, bool) {
-	code, exists := kafkaConsumer.smsStorage.Get(phoneNumber)
-	if !exists {
-		return "", false
+func isSmsCodeOfLength(smsCode string, codeLength int) bool {
+	if len(smsCode) != codeLength {
+		return false
 	}
 
-	return code, true
+	for _, symbol := range smsCode {
+		if symbol < '0' || symbol > '9' {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (kafkaConsumer *confluentKafkaConsumer) ensureTopicExists() error {
@@ -134,8 +145,8 @@ func (kafkaConsumer *confluentKafkaConsumer) ensureTopicExists() error {
 	// Создаем топик
 	topicSpec := kafka.TopicSpecification{
 		Topic:             consumerTopicName,
-		NumPartitions:     1,
-		ReplicationFactor: 1,
+		NumPartitions:     kafkaConsumer.topicPartitions,
+		ReplicationFactor: kafkaConsumer.topicReplicationFactor,
 	}
 
 	results, err := adminClient.CreateTopics(
@@ -161,7 +172,7 @@ func (kafkaConsumer *confluentKafkaConsumer) ensureTopicExists() error {
 
 var singletoneKafkaConsumer = &confluentKafkaConsumer{}
 
-func InitKafkaConsumer(config KafkaConfig, logger *zap.Logger) (KafkaConsumer, error) {
+func InitKafkaConsumer(config KafkaConfig, smsConfig SmsConfig, smsStorage SmsStorage, phoneParser phone.Parser, logger *zap.Logger) (KafkaConsumer, error) {
 	if singletoneKafkaConsumer.kafkaConsumer == nil {
 		consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
 			"bootstrap.servers": config.Url,
@@ -181,10 +192,12 @@ func InitKafkaConsumer(config KafkaConfig, logger *zap.Logger) (KafkaConsumer, e
 		}
 
 		singletoneKafkaConsumer.kafkaConsumer = consumer
-		singletoneKafkaConsumer.smsStorage = NewSmsStorage()
+		singletoneKafkaConsumer.smsStorage = smsStorage
+		singletoneKafkaConsumer.phoneParser = phoneParser
+		singletoneKafkaConsumer.codeLength = smsConfig.CodeLength
+		singletoneKafkaConsumer.topicPartitions = config.TopicPartitions
+		singletoneKafkaConsumer.topicReplicationFactor = config.TopicReplicationFactor
 		singletoneKafkaConsumer.logger = logger
-
-		compiledPhoneNumberRegex, _ = regexp.Compile(`^(8|\+7)(\s|\(|-)?(\d{3})(\s|\)|-)?(\d{3})(\s|-)?(\d{2})(\s|-)?(\d{2})$`)
 	}
 
 	return singletoneKafkaConsumer, nil
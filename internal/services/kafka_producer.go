@@ -5,47 +5,188 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 type KafkaProducer interface {
-	SendPhoneNumber(phoneNumber string) error
+	// Asks SmsService to generate code and send it to phone number
+	SendPhoneNumber(ctx context.Context, phoneNumber string) error
+
+	// Asks SmsService to send code generated by AuthService
+	SendSmsCode(ctx context.Context, phoneNumber string, smsCode string) error
+
+	// Publishes event about user to topic kafka.user_events_topic, id of user is key of message
+	PublishUserEvent(ctx context.Context, event UserEvent) error
+
+	// Publishes any event encoded in json. Events with the same key get into one partition, so they are received in order
+	PublishEvent(ctx context.Context, topicName string, key string, event interface{}) error
+
+	// Publishes already encoded message
+	Publish(ctx context.Context, topicName string, key []byte, payload []byte) error
+}
+
+const (
+	UserEventRegistered   = "user.registered"
+	UserEventLoggedIn     = "user.logged_in"
+	UserEventRoleChanged  = "user.role_changed"
+	UserEventPhoneChanged = "user.phone_changed"
+	UserEventDeleted      = "user.deleted"
+	UserEventRestored     = "user.restored"
+)
+
+// Version of envelope of user events. It's increased on incompatible changes of envelope or payload of any event,
+// new fields are added without changing version
+const UserEventVersion = 1
+
+// Envelope of all events about user
+type UserEvent struct {
+	Version int `json:"version"`
+
+	// Unique id of event, consumers can use it to skip event delivered twice
+	Id uuid.UUID `json:"event_id"`
+
+	Type       string    `json:"type"`
+	UserId     uuid.UUID `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// Payload specific for type of event
+	Data interface{} `json:"data"`
+}
+
+func NewUserEvent(eventType string, userId uuid.UUID, occurredAt time.Time, data interface{}) UserEvent {
+	return UserEvent{
+		Version:    UserEventVersion,
+		Id:         uuid.New(),
+		Type:       eventType,
+		UserId:     userId,
+		OccurredAt: occurredAt,
+		Data:       data,
+	}
+}
+
+type UserRegisteredEventData struct {
+	PhoneNumber string `json:"phone_number"`
+	Role        string `json:"role"`
+
+	// "pending_approval" if role requires approval by admin, otherwise "active"
+	Status string `json:"status"`
+}
+
+type UserLoggedInEventData struct {
+	// True if user was registered by this login
+	IsNewUser bool `json:"is_new_user"`
+}
+
+// Roles of user after change, sessions of user are revoked, so new roles are applied at next login
+type UserRoleChangedEventData struct {
+	OldRole         string   `json:"old_role"`
+	Role            string   `json:"role"`
+	AdditionalRoles []string `json:"additional_roles"`
+}
+
+type PhoneChangedEventData struct {
+	OldPhoneNumber string `json:"old_phone_number"`
+	NewPhoneNumber string `json:"new_phone_number"`
+}
+
+type UserDeletedEventData struct {
+	// After this moment AuthService doesn't have any data of user
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// Deletion is cancelled, data of user is not purged
+type UserRestoredEventData struct {
+	// Status user had before deletion
+	Status string `json:"status"`
 }
 
 type confluentKafkaProducer struct {
-	kafkaProducer *kafka.Producer
-	logger        *zap.Logger
+	kafkaProducer          *kafka.Producer
+	userEventsTopic        string
+	topicPartitions        int
+	topicReplicationFactor int
+	logger                 *zap.Logger
 }
 
 type phoneNumberRequestDto struct {
 	PhoneNumber string `json:"phone_number"`
+	SmsCode     string `json:"sms_code,omitempty"`
 }
 
 var producerTopicName = "auth-to-sms"
 var singletoneKafkaProducer *confluentKafkaProducer = &confluentKafkaProducer{}
 
-func (kafkaProducer *confluentKafkaProducer) SendPhoneNumber(phoneNumber string) error {
-	err := kafkaProducer.ensureTopicExists()
+func (kafkaProducer *confluentKafkaProducer) SendPhoneNumber(ctx context.Context, phoneNumber string) error {
+	return kafkaProducer.produce(producerTopicName, nil, phoneNumberRequestDto{PhoneNumber: phoneNumber})
+}
+
+func (kafkaProducer *confluentKafkaProducer) SendSmsCode(ctx context.Context, phoneNumber string, smsCode string) error {
+	return kafkaProducer.produce(producerTopicName, nil, phoneNumberRequestDto{PhoneNumber: phoneNumber, SmsCode: smsCode})
+}
+
+func (kafkaProducer *confluentKafkaProducer) PublishUserEvent(ctx context.Context, event UserEvent) error {
+	// Events of one user get into one partition, so consumers receive them in order
+	return kafkaProducer.PublishEvent(ctx, kafkaProducer.userEventsTopic, event.UserId.String(), event)
+}
+
+func (kafkaProducer *confluentKafkaProducer) PublishEvent(ctx context.Context, topicName string, key string, event interface{}) error {
+	return kafkaProducer.produce(topicName, []byte(key), event)
+}
+
+// Unlike other methods, waits until kafka acknowledges message, so caller knows that message is not lost
+func (kafkaProducer *confluentKafkaProducer) Publish(ctx context.Context, topicName string, key []byte, payload []byte) error {
+	deliveryChannel := make(chan kafka.Event, 1)
+
+	err := kafkaProducer.produceEncoded(topicName, key, payload, deliveryChannel)
 	if err != nil {
-		kafkaProducer.logger.Error("failed to ensure topic exists, proceeding anyway...", zap.Error(err))
 		return err
 	}
 
-	dto := phoneNumberRequestDto{PhoneNumber: phoneNumber}
+	select {
+	case event := <-deliveryChannel:
+		message, isMessage := event.(*kafka.Message)
+		if !isMessage {
+			return fmt.Errorf("while delivering message to kafka happened unexpected event: %v", event)
+		}
+
+		if message.TopicPartition.Error != nil {
+			return fmt.Errorf("while delivering message to kafka happened error: %w", message.TopicPartition.Error)
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (kafkaProducer *confluentKafkaProducer) produce(topicName string, key []byte, dto interface{}) error {
 	encodedMessage, err := json.Marshal(dto)
+	if err != nil {
+		return errors.New("while encoding message in dto happened error: " + err.Error())
+	}
 
+	return kafkaProducer.produceEncoded(topicName, key, encodedMessage, nil)
+}
+
+// Delivery report of message is sent to deliveryChannel, if it's nil - to events of producer
+func (kafkaProducer *confluentKafkaProducer) produceEncoded(topicName string, key []byte, encodedMessage []byte, deliveryChannel chan kafka.Event) error {
+	err := kafkaProducer.ensureTopicExists(topicName)
 	if err != nil {
-		return errors.New("while encoding phone number in dto happened error: " + err.Error())
+		kafkaProducer.logger.Error("failed to ensure topic exists, proceeding anyway...", zap.Error(err))
+		return err
 	}
 
 	message := &kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &producerTopicName, Partition: kafka.PartitionAny},
+		TopicPartition: kafka.TopicPartition{Topic: &topicName, Partition: kafka.PartitionAny},
+		Key:            key,
 		Value:          encodedMessage,
 	}
 
-	err = kafkaProducer.kafkaProducer.Produce(message, nil)
+	err = kafkaProducer.kafkaProducer.Produce(message, deliveryChannel)
 	if err != nil {
 		return errors.New("while producing message in kafka happened error: " + err.Error())
 	}
@@ -53,27 +194,27 @@ func (kafkaProducer *confluentKafkaProducer) SendPhoneNumber(phoneNumber string)
 	return nil
 }
 
-func (kafkaProducer *confluentKafkaProducer) ensureTopicExists() error {
+func (kafkaProducer *confluentKafkaProducer) ensureTopicExists(topicName string) error {
 	adminClient, err := kafka.NewAdminClientFromProducer(kafkaProducer.kafkaProducer)
 	if err != nil {
 		return fmt.Errorf("failed to create admin client: %w", err)
 	}
 	defer adminClient.Close()
 
-	metadata, err := adminClient.GetMetadata(&producerTopicName, false, 5000)
+	metadata, err := adminClient.GetMetadata(&topicName, false, 5000)
 	if err != nil {
 		return fmt.Errorf("failed to get metadata: %w", err)
 	}
 
-	if _, exists := metadata.Topics[producerTopicName]; exists {
-		kafkaProducer.logger.Info("topic already exists", zap.String("topic", producerTopicName))
+	if _, exists := metadata.Topics[topicName]; exists {
+		kafkaProducer.logger.Info("topic already exists", zap.String("topic", topicName))
 		return nil
 	}
 
 	topicSpec := kafka.TopicSpecification{
-		Topic:             producerTopicName,
-		NumPartitions:     1,
-		ReplicationFactor: 1,
+		Topic:             topicName,
+		NumPartitions:     kafkaProducer.topicPartitions,
+		ReplicationFactor: kafkaProducer.topicReplicationFactor,
 	}
 
 	results, err := adminClient.CreateTopics(
@@ -93,7 +234,45 @@ func (kafkaProducer *confluentKafkaProducer) ensureTopicExists() error {
 		}
 	}
 
-	kafkaProducer.logger.Info("topic successfully created", zap.String("topic", producerTopicName))
+	kafkaProducer.logger.Info("topic successfully created", zap.String("topic", topicName))
+	return nil
+}
+
+// Producer for local run without kafka (see storage "memory"): messages are only written to log,
+// so sms codes generated by service can be taken from there. Must not be used in production
+type LoggingKafkaProducer struct {
+	logger *zap.Logger
+}
+
+func NewLoggingKafkaProducer(logger *zap.Logger) *LoggingKafkaProducer {
+	return &LoggingKafkaProducer{logger: logger}
+}
+
+func (kafkaProducer *LoggingKafkaProducer) SendPhoneNumber(ctx context.Context, phoneNumber string) error {
+	kafkaProducer.logger.Info("sms code is requested from SmsService", zap.String("phone_number", phoneNumber))
+	return nil
+}
+
+func (kafkaProducer *LoggingKafkaProducer) SendSmsCode(ctx context.Context, phoneNumber string, smsCode string) error {
+	kafkaProducer.logger.Info("sms code is sent", zap.String("phone_number", phoneNumber), zap.String("sms_code", smsCode))
+	return nil
+}
+
+func (kafkaProducer *LoggingKafkaProducer) PublishUserEvent(ctx context.Context, event UserEvent) error {
+	kafkaProducer.logger.Info("user event is published", zap.String("type", event.Type), zap.String("user_id", event.UserId.String()))
+	return nil
+}
+
+func (kafkaProducer *LoggingKafkaProducer) PublishEvent(ctx context.Context, topicName string, key string, event interface{}) error {
+	kafkaProducer.logger.Info("event is published", zap.String("topic", topicName), zap.String("key", key), zap.Any("event", event))
+	return nil
+}
+
+func (kafkaProducer *LoggingKafkaProducer) Publish(ctx context.Context, topicName string, key []byte, payload []byte) error {
+	kafkaProducer.logger.Info("message is published",
+		zap.String("topic", topicName),
+		zap.ByteString("key", key),
+		zap.ByteString("payload", payload))
 	return nil
 }
 
@@ -115,6 +294,9 @@ func NewKafkaProducer(config KafkaConfig, logger *zap.Logger) (KafkaProducer, er
 		}
 
 		singletoneKafkaProducer.kafkaProducer = producer
+		singletoneKafkaProducer.userEventsTopic = config.UserEventsTopic
+		singletoneKafkaProducer.topicPartitions = config.TopicPartitions
+		singletoneKafkaProducer.topicReplicationFactor = config.TopicReplicationFactor
 		singletoneKafkaProducer.logger = logger
 
 		// Запускаем горутину для обработки delivery reports (иначе могут быть memory leaks)
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrKeyRotationDisabled = errors.New("key rotation is disabled: signing key is taken from config")
+
+type SigningKey struct {
+	Id     string
+	Method jwt.SigningMethod
+
+	// []byte for HS256, crypto.Signer for asymmetric algorithms
+	PrivateKey interface{}
+
+	// []byte for HS256, crypto.PublicKey for asymmetric algorithms
+	PublicKey interface{}
+
+	CreatedAt time.Time
+}
+
+type Keyring interface {
+	// Key for signing new tokens
+	Current() *SigningKey
+
+	// Key for verification of token with passed "kid" (current or retired, but not expired)
+	Get(keyId string) (*SigningKey, bool)
+
+	// Key for verification of tokens issued before keyring appeared (without "kid"), nil if there is no such key
+	Legacy() *SigningKey
+
+	VerificationKeys() []*SigningKey
+
+	// Replaces current signing key with new one. Old key stays valid for verification until tokens signed by it expire
+	Rotate(ctx context.Context) error
+}
+
+// Keyring with two sources of keys:
+//   - static key from config (secret_key or private_key_path)
+//   - managed keys, generated by service and stored in database, so all replicas share them
+//
+// Managed keys are used when rotation is enabled or asymmetric key wasn't configured,
+// static key then remains valid for verification only
+type DbKeyring struct {
+	mutex sync.RWMutex
+
+	logger     *zap.Logger
+	repository repositories.SigningKeyRepository
+
+	algorithm        string
+	method           jwt.SigningMethod
+	rotationInterval time.Duration
+	retiredKeyTtl    time.Duration
+
+	staticKey   *SigningKey
+	isManaged   bool
+	managedKeys map[string]*SigningKey
+	current     *SigningKey
+}
+
+func InitKeyring(secretKey string, config TokenConfig, repository repositories.SigningKeyRepository, logger *zap.Logger) (*DbKeyring, error) {
+	method, exists := supportedSigningMethods[config.Algorithm]
+	if !exists {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", config.Algorithm)
+	}
+
+	keyring := &DbKeyring{
+		logger:           logger,
+		repository:       repository,
+		algorithm:        config.Algorithm,
+		method:           method,
+		rotationInterval: time.Duration(config.KeyRotationIntervalHours) * time.Hour,
+		retiredKeyTtl:    config.AccessTokenLifetime(),
+		managedKeys:      make(map[string]*SigningKey),
+	}
+
+	staticKey, err := loadStaticKey(secretKey, config, method)
+	if err != nil {
+		return nil, err
+	}
+	keyring.staticKey = staticKey
+
+	keyring.isManaged = keyring.rotationInterval > 0 || staticKey == nil
+	if !keyring.isManaged {
+		keyring.current = staticKey
+		return keyring, nil
+	}
+
+	err = keyring.reload(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if keyring.current == nil {
+		err = keyring.Rotate(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+// Periodically picks up keys rotated by other replicas and rotates current key when it gets too old
+func (keyring *DbKeyring) Start() {
+	if !keyring.isManaged {
+		return
+	}
+
+	ctx := context.Background()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := keyring.reload(ctx)
+		if err != nil {
+			keyring.logger.Error("while reloading signing keys happened error", zap.Error(err))
+			continue
+		}
+
+		if keyring.rotationInterval > 0 && time.Since(keyring.Current().CreatedAt) >= keyring.rotationInterval {
+			err = keyring.Rotate(ctx)
+			if err != nil {
+				keyring.logger.Error("while rotating signing key happened error", zap.Error(err))
+				continue
+			}
+
+			keyring.logger.Info("signing key was rotated by schedule", zap.String("kid", keyring.Current().Id))
+		}
+
+		err = keyring.repository.DeleteExpired(ctx)
+		if err != nil {
+			keyring.logger.Error("while deleting expired signing keys happened error", zap.Error(err))
+		}
+	}
+}
+
+func (keyring *DbKeyring) Current() *SigningKey {
+	keyring.mutex.RLock()
+	defer keyring.mutex.RUnlock()
+
+	return keyring.current
+}
+
+func (keyring *DbKeyring) Get(keyId string) (*SigningKey, bool) {
+	keyring.mutex.RLock()
+	defer keyring.mutex.RUnlock()
+
+	if keyring.staticKey != nil && keyring.staticKey.Id == keyId {
+		return keyring.staticKey, true
+	}
+
+	key, exists := keyring.managedKeys[keyId]
+	return key, exists
+}
+
+func (keyring *DbKeyring) Legacy() *SigningKey {
+	return keyring.staticKey
+}
+
+func (keyring *DbKeyring) VerificationKeys() []*SigningKey {
+	keyring.mutex.RLock()
+	defer keyring.mutex.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(keyring.managedKeys)+1)
+	if keyring.staticKey != nil {
+		keys = append(keys, keyring.staticKey)
+	}
+
+	for _, key := range keyring.managedKeys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (keyring *DbKeyring) Rotate(ctx context.Context) error {
+	if !keyring.isManaged {
+		return ErrKeyRotationDisabled
+	}
+
+	privateKeyBytes, err := generateManagedPrivateKey(keyring.algorithm)
+	if err != nil {
+		return err
+	}
+
+	keyEntity := &entities.SigningKey{
+		Id:         uuid.NewString(),
+		Algorithm:  keyring.algorithm,
+		PrivateKey: privateKeyBytes,
+		CreatedAt:  time.Now(),
+	}
+
+	err = keyring.repository.Add(ctx, keyEntity)
+	if err != nil {
+		return err
+	}
+
+	err = keyring.repository.RetireAllExcept(ctx, keyring.algorithm, keyEntity.Id, time.Now().Add(keyring.retiredKeyTtl))
+	if err != nil {
+		return err
+	}
+
+	return keyring.reload(ctx)
+}
+
+func (keyring *DbKeyring) reload(ctx context.Context) error {
+	keyEntities, err := keyring.repository.GetActive(ctx, keyring.algorithm)
+	if err != nil {
+		return err
+	}
+
+	managedKeys := make(map[string]*SigningKey, len(keyEntities))
+	var current *SigningKey
+
+	// Keys are sorted from newest, so first not retired key is current
+	for _, keyEntity := range keyEntities {
+		key, err := parseManagedKey(keyEntity, keyring.method)
+		if err != nil {
+			return err
+		}
+
+		managedKeys[key.Id] = key
+		if current == nil && keyEntity.RetiredAt == nil {
+			current = key
+		}
+	}
+
+	// Concurrent rotation on several replicas could retire each other keys, then newest key wins
+	if current == nil && len(keyEntities) > 0 {
+		current = managedKeys[keyEntities[0].Id]
+	}
+
+	keyring.mutex.Lock()
+	defer keyring.mutex.Unlock()
+
+	keyring.managedKeys = managedKeys
+	if current != nil {
+		keyring.current = current
+	}
+
+	return nil
+}
+
+func loadStaticKey(secretKey string, config TokenConfig, method jwt.SigningMethod) (*SigningKey, error) {
+	if isSymmetricAlgorithm(config.Algorithm) {
+		if secretKey == "" {
+			return nil, nil
+		}
+
+		// kid is derived from secret, so all replicas with same secret produce same kid
+		secretHash := sha256.Sum256([]byte(secretKey))
+		return &SigningKey{
+			Id:         hex.EncodeToString(secretHash[:8]),
+			Method:     method,
+			PrivateKey: []byte(secretKey),
+			PublicKey:  []byte(secretKey),
+		}, nil
+	}
+
+	if config.PrivateKeyPath == "" {
+		return nil, nil
+	}
+
+	privateKey, err := loadPrivateKey(config.Algorithm, config.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyId, err := computeKeyThumbprint(config.Algorithm, privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		Id:         keyId,
+		Method:     method,
+		PrivateKey: privateKey,
+		PublicKey:  privateKey.Public(),
+	}, nil
+}
+
+func generateManagedPrivateKey(algorithm string) ([]byte, error) {
+	if isSymmetricAlgorithm(algorithm) {
+		secret := make([]byte, 32)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return nil, fmt.Errorf("while generating secret happened error: %w", err)
+		}
+
+		return secret, nil
+	}
+
+	privateKey, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("while generating private key happened error: %w", err)
+	}
+
+	return x509.MarshalPKCS8PrivateKey(privateKey)
+}
+
+func parseManagedKey(keyEntity entities.SigningKey, method jwt.SigningMethod) (*SigningKey, error) {
+	key := &SigningKey{Id: keyEntity.Id, Method: method, CreatedAt: keyEntity.CreatedAt}
+
+	if isSymmetricAlgorithm(keyEntity.Algorithm) {
+		key.PrivateKey = keyEntity.PrivateKey
+		key.PublicKey = keyEntity.PrivateKey
+		return key, nil
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(keyEntity.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing signing key %s happened error: %w", keyEntity.Id, err)
+	}
+
+	signer, isSigner := privateKey.(crypto.Signer)
+	if !isSigner {
+		return nil, fmt.Errorf("signing key %s can't be used for signing", keyEntity.Id)
+	}
+
+	key.PrivateKey = signer
+	key.PublicKey = signer.Public()
+	return key, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"go.uber.org/zap"
+)
+
+// Time given to kafka to acknowledge one message before it's retried
+const outboxPublishTimeout = time.Minute
+
+// Claimed messages are not given to other relays while they are published and marked
+const outboxClaimDuration = 2 * outboxPublishTimeout
+
+// Producer which saves messages to outbox instead of sending them, they are sent by OutboxRelay.
+// Message is saved in transaction of ctx, so it's sent only if changes it's about are committed,
+// and request doesn't fail if kafka is unavailable
+type OutboxKafkaProducer struct {
+	repository      repositories.OutboxRepository
+	kafkaProducer   KafkaProducer
+	userEventsTopic string
+}
+
+func NewOutboxKafkaProducer(repository repositories.OutboxRepository, kafkaProducer KafkaProducer, config KafkaConfig) *OutboxKafkaProducer {
+	return &OutboxKafkaProducer{repository: repository, kafkaProducer: kafkaProducer, userEventsTopic: config.UserEventsTopic}
+}
+
+// Requests of one phone number are sent in order
+func (kafkaProducer *OutboxKafkaProducer) SendPhoneNumber(ctx context.Context, phoneNumber string) error {
+	return kafkaProducer.PublishEvent(ctx, producerTopicName, phoneNumber, phoneNumberRequestDto{PhoneNumber: phoneNumber})
+}
+
+// Sent directly, because plain sms code must not be stored, and code which is sent late is useless anyway
+func (kafkaProducer *OutboxKafkaProducer) SendSmsCode(ctx context.Context, phoneNumber string, smsCode string) error {
+	return kafkaProducer.kafkaProducer.SendSmsCode(ctx, phoneNumber, smsCode)
+}
+
+func (kafkaProducer *OutboxKafkaProducer) PublishUserEvent(ctx context.Context, event UserEvent) error {
+	return kafkaProducer.PublishEvent(ctx, kafkaProducer.userEventsTopic, event.UserId.String(), event)
+}
+
+func (kafkaProducer *OutboxKafkaProducer) PublishEvent(ctx context.Context, topicName string, key string, event interface{}) error {
+	encodedEvent, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("while encoding event happened error: %w", err)
+	}
+
+	return kafkaProducer.Publish(ctx, topicName, []byte(key), encodedEvent)
+}
+
+func (kafkaProducer *OutboxKafkaProducer) Publish(ctx context.Context, topicName string, key []byte, payload []byte) error {
+	now := time.Now()
+
+	return kafkaProducer.repository.Add(ctx, &entities.OutboxMessage{
+		Topic:         topicName,
+		Key:           string(key),
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	})
+}
+
+// Sends messages saved by OutboxKafkaProducer to kafka and deletes them some time after delivery.
+// Every replica can run relay: messages are claimed before they are sent, so each of them is sent by one replica
+type OutboxRelay struct {
+	logger        *zap.Logger
+	repository    repositories.OutboxRepository
+	kafkaProducer KafkaProducer
+	config        OutboxConfig
+}
+
+func NewOutboxRelay(repository repositories.OutboxRepository,
+	kafkaProducer KafkaProducer,
+	config OutboxConfig,
+	logger *zap.Logger) *OutboxRelay {
+
+	return &OutboxRelay{
+		logger:        logger,
+		repository:    repository,
+		kafkaProducer: kafkaProducer,
+		config:        config,
+	}
+}
+
+func (relay *OutboxRelay) Start() {
+	ctx := context.Background()
+
+	ticker := time.NewTicker(relay.config.PollInterval())
+	defer ticker.Stop()
+
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			relay.sendPending(ctx)
+		case <-cleanupTicker.C:
+			relay.deleteDelivered(ctx)
+		}
+	}
+}
+
+// Sends batches while they are full and delivered, so accumulated messages don't wait for ticker
+func (relay *OutboxRelay) sendPending(ctx context.Context) {
+	for {
+		hasMore, err := relay.sendBatch(ctx)
+		if err != nil {
+			relay.logger.Error("while sending outbox messages happened error", zap.Error(err))
+			return
+		}
+
+		if !hasMore {
+			return
+		}
+	}
+}
+
+// Messages of batch have different keys, so they are published concurrently and relay waits for kafka once per batch.
+// No transaction is open while messages are published, every message is marked by separate statement
+func (relay *OutboxRelay) sendBatch(ctx context.Context) (bool, error) {
+	now := time.Now()
+
+	messages, err := relay.repository.ClaimPending(ctx, now, now.Add(outboxClaimDuration), relay.config.BatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	sendErrors := make([]error, len(messages))
+
+	var waitGroup sync.WaitGroup
+	for i, message := range messages {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			sendErrors[i] = relay.send(ctx, message)
+		}()
+	}
+	waitGroup.Wait()
+
+	isEveryDelivered := true
+	for i, message := range messages {
+		if sendErrors[i] != nil {
+			isEveryDelivered = false
+		}
+
+		// Not marked message is sent again after claim expires
+		err = relay.mark(ctx, message, sendErrors[i])
+		if err != nil {
+			isEveryDelivered = false
+			relay.logger.Error("while marking outbox message happened error", zap.Int64("id", message.Id), zap.Error(err))
+		}
+	}
+
+	// Usually kafka is unavailable when some message failed, so the rest wait for next tick
+	return isEveryDelivered && len(messages) == relay.config.BatchSize, nil
+}
+
+func (relay *OutboxRelay) mark(ctx context.Context, message *entities.OutboxMessage, sendError error) error {
+	if sendError == nil {
+		return relay.repository.MarkDelivered(ctx, message.Id, time.Now())
+	}
+
+	attempt := message.Attempts + 1
+	if attempt >= relay.config.MaxAttempts {
+		relay.logger.Error("outbox message failed max amount of attempts and won't be sent anymore",
+			zap.Int64("id", message.Id),
+			zap.String("topic", message.Topic),
+			zap.Int("attempt", attempt),
+			zap.Error(sendError))
+
+		return relay.repository.MarkDead(ctx, message.Id, sendError.Error(), time.Now())
+	}
+
+	nextAttemptAt := time.Now().Add(relay.retryDelay(message.Attempts))
+	relay.logger.Error("while sending outbox message happened error",
+		zap.Int64("id", message.Id),
+		zap.String("topic", message.Topic),
+		zap.Int("attempt", attempt),
+		zap.Time("next_attempt_at", nextAttemptAt),
+		zap.Error(sendError))
+
+	return relay.repository.MarkFailed(ctx, message.Id, sendError.Error(), nextAttemptAt)
+}
+
+func (relay *OutboxRelay) send(ctx context.Context, message *entities.OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+	defer cancel()
+
+	var key []byte
+	if message.Key != "" {
+		key = []byte(message.Key)
+	}
+
+	return relay.kafkaProducer.Publish(ctx, message.Topic, key, message.Payload)
+}
+
+// Delay doubles with every failed attempt up to max_retry_delay_seconds
+func (relay *OutboxRelay) retryDelay(failedAttempts int) time.Duration {
+	delay := relay.config.RetryDelay()
+	for range failedAttempts {
+		if delay >= relay.config.MaxRetryDelay() {
+			break
+		}
+
+		delay *= 2
+	}
+
+	return min(delay, relay.config.MaxRetryDelay())
+}
+
+func (relay *OutboxRelay) deleteDelivered(ctx context.Context) {
+	amountOfDeleted, err := relay.repository.DeleteDelivered(ctx, time.Now().Add(-relay.config.DeliveredRetention()))
+	if err != nil {
+		relay.logger.Error("while deleting delivered outbox messages happened error", zap.Error(err))
+		return
+	}
+
+	if amountOfDeleted > 0 {
+		relay.logger.Info("delivered outbox messages were deleted", zap.Int64("amount", amountOfDeleted))
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Rule of sliding window limit: not more than Limit actions with Key during last Window
+type RateLimitRule struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimitResult struct {
+	IsAllowed bool
+
+	// Set when action is not allowed: how long to wait until it will be allowed
+	RetryAfter time.Duration
+
+	// Set when action is not allowed: keys of rules which don't allow it
+	BlockedKeys []string
+}
+
+func (result RateLimitResult) IsBlockedBy(key string) bool {
+	return slices.Contains(result.BlockedKeys, key)
+}
+
+type RateLimiter interface {
+	// Allows action only if every rule allows it, then action is counted for every rule.
+	// Rule passed several times counts action several times (e.g. one request sends SMS to two phone numbers).
+	// Rules with non-positive limit are ignored
+	Allow(rules ...RateLimitRule) (RateLimitResult, error)
+
+	// Periodically removes events which are out of their windows
+	StartCleanup()
+}
+
+const (
+	RateLimiterMemory   = "memory"
+	RateLimiterPostgres = "postgres"
+)
+
+// Memory limiter counts only requests of its replica, so with several replicas postgres limiter should be used
+func InitRateLimiter(config RateLimitConfig, connection *sql.DB, logger *zap.Logger) (RateLimiter, error) {
+	switch config.Storage {
+	case RateLimiterMemory:
+		return NewInMemoryRateLimiter(), nil
+	case RateLimiterPostgres:
+		return NewPgRateLimiter(connection, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limiter storage: %s", config.Storage)
+	}
+}
+
+// Rule with amount of times it was passed to Allow
+type countedRateLimitRule struct {
+	RateLimitRule
+	amount int
+}
+
+// Leaves rules with positive limit, rules with the same key are merged, first of them is kept
+func activeRules(rules []RateLimitRule) []countedRateLimitRule {
+	countedRules := []countedRateLimitRule{}
+	for _, rule := range rules {
+		if rule.Limit <= 0 || rule.Window <= 0 {
+			continue
+		}
+
+		index := slices.IndexFunc(countedRules, func(countedRule countedRateLimitRule) bool { return countedRule.Key == rule.Key })
+		if index != -1 {
+			countedRules[index].amount++
+			continue
+		}
+
+		countedRules = append(countedRules, countedRateLimitRule{RateLimitRule: rule, amount: 1})
+	}
+
+	return countedRules
+}
+
+type InMemoryRateLimiter struct {
+	mutex sync.Mutex
+
+	// format: key: {window: time.Duration, events: [time.Time, ...]}, events are sorted from oldest
+	logs map[string]*rateLimitLog
+}
+
+type rateLimitLog struct {
+	window time.Duration
+	events []time.Time
+}
+
+func NewInMemoryRateLimiter() *InMemoryRateLimiter {
+	return &InMemoryRateLimiter{logs: make(map[string]*rateLimitLog)}
+}
+
+func (limiter *InMemoryRateLimiter) Allow(rules ...RateLimitRule) (RateLimitResult, error) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	countedRules := activeRules(rules)
+
+	result := RateLimitResult{IsAllowed: true}
+	for _, rule := range countedRules {
+		var eventsInWindow []time.Time
+		if log, exists := limiter.logs[rule.Key]; exists {
+			eventsInWindow = eventsSince(log.events, now.Add(-rule.Window))
+		}
+
+		if len(eventsInWindow)+rule.amount > rule.Limit {
+			// Action becomes allowed when enough oldest events leave the window, or never if it's counted more times than limit
+			retryAfter := rule.Window
+			if rule.amount <= rule.Limit {
+				retryAfter = eventsInWindow[len(eventsInWindow)+rule.amount-rule.Limit-1].Add(rule.Window).Sub(now)
+			}
+
+			result.IsAllowed = false
+			result.RetryAfter = max(result.RetryAfter, retryAfter)
+			result.BlockedKeys = append(result.BlockedKeys, rule.Key)
+		}
+	}
+
+	if !result.IsAllowed {
+		return result, nil
+	}
+
+	for _, rule := range countedRules {
+		log, exists := limiter.logs[rule.Key]
+		if !exists {
+			log = &rateLimitLog{}
+			limiter.logs[rule.Key] = log
+		}
+
+		log.window = max(log.window, rule.Window)
+		log.events = eventsSince(log.events, now.Add(-log.window))
+		for range rule.amount {
+			log.events = append(log.events, now)
+		}
+	}
+
+	return result, nil
+}
+
+func (limiter *InMemoryRateLimiter) StartCleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		limiter.mutex.Lock()
+		now := time.Now()
+		for key, log := range limiter.logs {
+			log.events = eventsSince(log.events, now.Add(-log.window))
+			if len(log.events) == 0 {
+				delete(limiter.logs, key)
+			}
+		}
+		limiter.mutex.Unlock()
+	}
+}
+
+func eventsSince(events []time.Time, since time.Time) []time.Time {
+	firstInWindow, _ := slices.BinarySearchFunc(events, since, func(event time.Time, target time.Time) int {
+		return event.Compare(target)
+	})
+
+	return events[firstInWindow:]
+}
+
+// RateLimiter shared by all replicas, events are kept in table rate_limit_events
+type PgRateLimiter struct {
+	connection *sql.DB
+	logger     *zap.Logger
+}
+
+func NewPgRateLimiter(connection *sql.DB, logger *zap.Logger) *PgRateLimiter {
+	return &PgRateLimiter{connection: connection, logger: logger}
+}
+
+func (limiter *PgRateLimiter) Allow(rules ...RateLimitRule) (RateLimitResult, error) {
+	countedRules := activeRules(rules)
+
+	// Keys are locked in the same order by everyone to avoid deadlocks
+	slices.SortFunc(countedRules, func(first countedRateLimitRule, second countedRateLimitRule) int {
+		return strings.Compare(first.Key, second.Key)
+	})
+
+	transaction, err := limiter.connection.Begin()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("while checking rate limits happened error: %w", err)
+	}
+	defer transaction.Rollback()
+
+	result, err := limiter.allowInTransaction(transaction, countedRules)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("while checking rate limits happened error: %w", err)
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("while checking rate limits happened error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (limiter *PgRateLimiter) allowInTransaction(transaction *sql.Tx, rules []countedRateLimitRule) (RateLimitResult, error) {
+	now := time.Now()
+	result := RateLimitResult{IsAllowed: true}
+
+	for _, rule := range rules {
+		_, err := transaction.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", rule.Key)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+
+		if rule.amount > rule.Limit {
+			result.IsAllowed = false
+			result.RetryAfter = max(result.RetryAfter, rule.Window)
+			result.BlockedKeys = append(result.BlockedKeys, rule.Key)
+			continue
+		}
+
+		// Action becomes allowed when enough oldest events leave the window
+		var blockingEventAt sql.NullTime
+		blockingEventQuery := `SELECT occurred_at FROM rate_limit_events
+			WHERE key = $1 AND occurred_at > $2
+			ORDER BY occurred_at DESC OFFSET $3 LIMIT 1`
+
+		err = transaction.QueryRow(blockingEventQuery, rule.Key, now.Add(-rule.Window), rule.Limit-rule.amount).Scan(&blockingEventAt)
+		if err != nil && err != sql.ErrNoRows {
+			return RateLimitResult{}, err
+		}
+
+		if blockingEventAt.Valid {
+			result.IsAllowed = false
+			result.RetryAfter = max(result.RetryAfter, blockingEventAt.Time.Add(rule.Window).Sub(now))
+			result.BlockedKeys = append(result.BlockedKeys, rule.Key)
+		}
+	}
+
+	if !result.IsAllowed {
+		return result, nil
+	}
+
+	for _, rule := range rules {
+		addEventQuery := "INSERT INTO rate_limit_events (key, occurred_at, expires_at) VALUES ($1, $2, $3)"
+
+		for range rule.amount {
+			_, err := transaction.Exec(addEventQuery, rule.Key, now, now.Add(rule.Window))
+			if err != nil {
+				return RateLimitResult{}, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (limiter *PgRateLimiter) StartCleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, err := limiter.connection.Exec("DELETE FROM rate_limit_events WHERE expires_at <= $1", time.Now())
+		if err != nil {
+			limiter.logger.Error("while deleting expired rate limit events happened error", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func allowAction(t *testing.T, limiter RateLimiter, rules ...RateLimitRule) RateLimitResult {
+	t.Helper()
+
+	result, err := limiter.Allow(rules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestRateLimiterAllowsUpToLimit(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	rule := RateLimitRule{Key: "sms:+79123456789", Limit: 3, Window: time.Minute}
+
+	for i := range rule.Limit {
+		if result := allowAction(t, limiter, rule); !result.IsAllowed {
+			t.Fatalf("action %d is rejected: %+v", i+1, result)
+		}
+	}
+
+	result := allowAction(t, limiter, rule)
+	if result.IsAllowed || result.RetryAfter <= 0 || result.RetryAfter > rule.Window {
+		t.Fatalf("expected action over limit to be rejected with retry after in window, got %+v", result)
+	}
+
+	if result := allowAction(t, limiter, RateLimitRule{Key: "sms:+79001112233", Limit: 3, Window: time.Minute}); !result.IsAllowed {
+		t.Fatalf("limit of another key is used: %+v", result)
+	}
+}
+
+func TestRateLimiterSlidesWindow(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	rule := RateLimitRule{Key: "sms:+79123456789", Limit: 2, Window: 300 * time.Millisecond}
+
+	allowAction(t, limiter, rule)
+	time.Sleep(100 * time.Millisecond)
+	allowAction(t, limiter, rule)
+
+	// First action leaves window first, so limit is freed before whole window passes since the second one
+	result := allowAction(t, limiter, rule)
+	if result.IsAllowed || result.RetryAfter > 200*time.Millisecond {
+		t.Fatalf("expected action to be rejected till the first one leaves window, got %+v", result)
+	}
+
+	time.Sleep(result.RetryAfter + 10*time.Millisecond)
+
+	if result := allowAction(t, limiter, rule); !result.IsAllowed {
+		t.Fatalf("expected action to be allowed after the oldest one left window, got %+v", result)
+	}
+
+	if result := allowAction(t, limiter, rule); result.IsAllowed {
+		t.Fatalf("expected the second action to be still in window, got %+v", result)
+	}
+}
+
+func TestRateLimiterCountsOnlyAllowedActions(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	strictRule := RateLimitRule{Key: "sms:+79123456789", Limit: 1, Window: time.Minute}
+	looseRule := RateLimitRule{Key: "ip:10.0.0.1", Limit: 3, Window: time.Minute}
+
+	allowAction(t, limiter, strictRule, looseRule)
+
+	for range 5 {
+		if result := allowAction(t, limiter, strictRule, looseRule); result.IsAllowed {
+			t.Fatalf("expected action to be rejected by strict rule, got %+v", result)
+		}
+	}
+
+	// Rejected actions are not counted by loose rule
+	for i := range looseRule.Limit - 1 {
+		if result := allowAction(t, limiter, looseRule); !result.IsAllowed {
+			t.Fatalf("action %d is rejected, rejected actions were counted: %+v", i+1, result)
+		}
+	}
+}
+
+func TestRateLimiterReturnsLongestRetryAfter(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	hourRule := RateLimitRule{Key: "sms:+79123456789:hour", Limit: 1, Window: time.Hour}
+	dayRule := RateLimitRule{Key: "sms:+79123456789:day", Limit: 1, Window: 24 * time.Hour}
+
+	allowAction(t, limiter, hourRule, dayRule)
+
+	result := allowAction(t, limiter, hourRule, dayRule)
+	if result.IsAllowed || result.RetryAfter <= time.Hour {
+		t.Fatalf("expected retry after of day rule, got %+v", result)
+	}
+}
+
+func TestRateLimiterIgnoresDisabledRules(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	disabledRules := []RateLimitRule{
+		{Key: "sms:+79123456789", Limit: 0, Window: time.Minute},
+		{Key: "ip:10.0.0.1", Limit: 1, Window: 0},
+	}
+
+	for range 3 {
+		if result := allowAction(t, limiter, disabledRules...); !result.IsAllowed {
+			t.Fatalf("disabled rule rejected action: %+v", result)
+		}
+	}
+}
+
+func TestRateLimiterReturnsBlockedKeys(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	cooldownRule := RateLimitRule{Key: "sms-cooldown:+79123456789", Limit: 1, Window: time.Minute}
+	hourRule := RateLimitRule{Key: "sms-hour:+79123456789", Limit: 2, Window: time.Hour}
+
+	allowAction(t, limiter, cooldownRule, hourRule)
+
+	result := allowAction(t, limiter, cooldownRule, hourRule)
+	if result.IsAllowed || len(result.BlockedKeys) != 1 || !result.IsBlockedBy(cooldownRule.Key) {
+		t.Fatalf("expected action to be rejected only by cooldown, got %+v", result)
+	}
+
+	allowAction(t, limiter, hourRule)
+
+	result = allowAction(t, limiter, cooldownRule, hourRule)
+	if result.IsAllowed || len(result.BlockedKeys) != 2 || !result.IsBlockedBy(cooldownRule.Key) || !result.IsBlockedBy(hourRule.Key) {
+		t.Fatalf("expected action to be rejected by both rules, got %+v", result)
+	}
+}
+
+func TestRateLimiterCountsRepeatedRuleSeveralTimes(t *testing.T) {
+	limiter := NewInMemoryRateLimiter()
+	ipRule := RateLimitRule{Key: "sms-hour:ip:10.0.0.1", Limit: 3, Window: time.Minute}
+
+	allowAction(t, limiter, ipRule)
+
+	// Two SMS in one request need two free slots
+	if result := allowAction(t, limiter, ipRule, ipRule); !result.IsAllowed {
+		t.Fatalf("expected two actions to fit into limit, got %+v", result)
+	}
+
+	if result := allowAction(t, limiter, ipRule); result.IsAllowed {
+		t.Fatalf("expected repeated rule to be counted twice, got %+v", result)
+	}
+
+	if result := allowAction(t, NewInMemoryRateLimiter(), ipRule, ipRule, ipRule, ipRule); result.IsAllowed || result.RetryAfter != ipRule.Window {
+		t.Fatalf("expected action counted more times than limit to be rejected, got %+v", result)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+)
+
+var ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+var ErrRefreshTokenReused = errors.New("refresh token was already used, its token family is revoked")
+
+// Rolls back rotation when concurrent request rotated the same token first
+var errRefreshTokenRotated = errors.New("refresh token was rotated by concurrent request")
+
+type RefreshTokenHandler interface {
+	// Issues refresh token which starts new token family (used on login)
+	Issue(ctx context.Context, userId uuid.UUID) (string, error)
+
+	// Revokes passed refresh token and issues new one in the same family.
+	// Returns id of token owner and new refresh token
+	Rotate(ctx context.Context, refreshToken string) (uuid.UUID, string, error)
+
+	// Revokes whole token family of passed refresh token (used on logout). Unknown token is ignored
+	Revoke(ctx context.Context, refreshToken string) error
+
+	// Revokes refresh tokens of all sessions of user
+	RevokeAll(ctx context.Context, userId uuid.UUID) error
+
+	// All refresh tokens of user (including revoked), newest first
+	GetAll(ctx context.Context, userId uuid.UUID) ([]entities.RefreshToken, error)
+}
+
+type DbRefreshTokenHandler struct {
+	repository        repositories.RefreshTokenRepository
+	transactionRunner repositories.TransactionRunner
+	lifetime          time.Duration
+}
+
+func NewRefreshTokenHandler(repository repositories.RefreshTokenRepository, transactionRunner repositories.TransactionRunner, config TokenConfig) *DbRefreshTokenHandler {
+	return &DbRefreshTokenHandler{
+		repository:        repository,
+		transactionRunner: transactionRunner,
+		lifetime:          time.Duration(config.RefreshTokenTtlHours) * time.Hour,
+	}
+}
+
+func (handler *DbRefreshTokenHandler) Issue(ctx context.Context, userId uuid.UUID) (string, error) {
+	refreshToken, _, err := handler.issue(ctx, userId, uuid.New())
+	return refreshToken, err
+}
+
+func (handler *DbRefreshTokenHandler) Rotate(ctx context.Context, refreshToken string) (uuid.UUID, string, error) {
+	storedToken, err := handler.repository.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if storedToken == nil {
+		return uuid.Nil, "", ErrRefreshTokenInvalid
+	}
+
+	// Token was already rotated (or revoked) - somebody uses stolen copy, so whole family is compromised
+	if storedToken.RevokedAt != nil {
+		return uuid.Nil, "", handler.revokeFamilyOnReuse(ctx, storedToken.FamilyId)
+	}
+
+	if time.Now().After(storedToken.ExpiresAt) {
+		return uuid.Nil, "", ErrRefreshTokenInvalid
+	}
+
+	// New token is issued only together with revocation of old one
+	var newRefreshToken string
+	err = handler.transactionRunner.InTransaction(ctx, func(ctx context.Context) error {
+		refreshToken, newTokenId, err := handler.issue(ctx, storedToken.UserId, storedToken.FamilyId)
+		if err != nil {
+			return err
+		}
+		newRefreshToken = refreshToken
+
+		isRevoked, err := handler.repository.Revoke(ctx, storedToken.Id, &newTokenId)
+		if err != nil {
+			return err
+		}
+
+		if !isRevoked {
+			return errRefreshTokenRotated
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errRefreshTokenRotated) {
+		return uuid.Nil, "", handler.revokeFamilyOnReuse(ctx, storedToken.FamilyId)
+	}
+
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return storedToken.UserId, newRefreshToken, nil
+}
+
+func (handler *DbRefreshTokenHandler) Revoke(ctx context.Context, refreshToken string) error {
+	storedToken, err := handler.repository.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return err
+	}
+
+	if storedToken == nil {
+		return nil
+	}
+
+	return handler.repository.RevokeFamily(ctx, storedToken.FamilyId)
+}
+
+func (handler *DbRefreshTokenHandler) RevokeAll(ctx context.Context, userId uuid.UUID) error {
+	return handler.repository.RevokeAllForUser(ctx, userId)
+}
+
+func (handler *DbRefreshTokenHandler) GetAll(ctx context.Context, userId uuid.UUID) ([]entities.RefreshToken, error) {
+	return handler.repository.GetByUser(ctx, userId)
+}
+
+func (handler *DbRefreshTokenHandler) issue(ctx context.Context, userId uuid.UUID, familyId uuid.UUID) (string, uuid.UUID, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", uuid.Nil, fmt.Errorf("while generating refresh token happened error: %w", err)
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	tokenEntity := &entities.RefreshToken{
+		Id:        uuid.New(),
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: hashRefreshToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(handler.lifetime),
+	}
+
+	err = handler.repository.Add(ctx, tokenEntity)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return refreshToken, tokenEntity.Id, nil
+}
+
+func (handler *DbRefreshTokenHandler) revokeFamilyOnReuse(ctx context.Context, familyId uuid.UUID) error {
+	err := handler.repository.RevokeFamily(ctx, familyId)
+	if err != nil {
+		return fmt.Errorf("while revoking reused refresh token family happened error: %w", err)
+	}
+
+	return ErrRefreshTokenReused
+}
+
+func hashRefreshToken(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type RevocationStore interface {
+	Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenId string) (bool, error)
+
+	// Revokes all tokens of user issued before passed time
+	RevokeUser(ctx context.Context, userId uuid.UUID, issuedBefore time.Time) error
+
+	// Checks if token of user issued at passed time was revoked by RevokeUser
+	IsUserRevoked(ctx context.Context, userId uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+// RevocationStore backed by database with in-process cache, so validation of token usually doesn't touch database.
+// Revoked state is cached until token expires (it can't change back), not revoked state - only for short time,
+// so revocation made on other replica is picked up with delay of at most cache lifetime
+type CachedRevocationStore struct {
+	mutex sync.RWMutex
+
+	logger     *zap.Logger
+	repository repositories.RevokedTokenRepository
+
+	notRevokedCacheTtl time.Duration
+	tokenLifetime      time.Duration
+
+	// format: jti: {isRevoked: true, validUntil: time.Time}
+	cache map[string]revocationCacheEntry
+
+	// format: user_id: {notBefore: time.Time, validUntil: time.Time}
+	userCutoffCache map[uuid.UUID]userCutoffCacheEntry
+}
+
+type revocationCacheEntry struct {
+	isRevoked  bool
+	validUntil time.Time
+}
+
+type userCutoffCacheEntry struct {
+	notBefore  time.Time
+	validUntil time.Time
+}
+
+func NewRevocationStore(repository repositories.RevokedTokenRepository, config TokenConfig, logger *zap.Logger) *CachedRevocationStore {
+	return &CachedRevocationStore{
+		logger:             logger,
+		repository:         repository,
+		notRevokedCacheTtl: time.Duration(config.RevocationCacheSeconds) * time.Second,
+		tokenLifetime:      config.AccessTokenLifetime(),
+		cache:              make(map[string]revocationCacheEntry),
+		userCutoffCache:    make(map[uuid.UUID]userCutoffCacheEntry),
+	}
+}
+
+func (store *CachedRevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	err := store.repository.Add(ctx, &entities.RevokedToken{TokenId: tokenId, RevokedAt: time.Now(), ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.cache[tokenId] = revocationCacheEntry{isRevoked: true, validUntil: expiresAt}
+	return nil
+}
+
+func (store *CachedRevocationStore) IsRevoked(ctx context.Context, tokenId string) (bool, error) {
+	store.mutex.RLock()
+	entry, exists := store.cache[tokenId]
+	store.mutex.RUnlock()
+
+	if exists && time.Now().Before(entry.validUntil) {
+		return entry.isRevoked, nil
+	}
+
+	isRevoked, err := store.repository.Exists(ctx, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	// Revoked state can't change back, so it's cached for whole lifetime of token
+	validUntil := time.Now().Add(store.notRevokedCacheTtl)
+	if isRevoked {
+		validUntil = time.Now().Add(store.tokenLifetime)
+	}
+
+	store.mutex.Lock()
+	store.cache[tokenId] = revocationCacheEntry{isRevoked: isRevoked, validUntil: validUntil}
+	store.mutex.Unlock()
+
+	return isRevoked, nil
+}
+
+func (store *CachedRevocationStore) RevokeUser(ctx context.Context, userId uuid.UUID, issuedBefore time.Time) error {
+	// After lifetime of token passes, there are no tokens issued before cutoff anymore
+	err := store.repository.SetUserCutoff(ctx, userId, issuedBefore, issuedBefore.Add(store.tokenLifetime))
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.userCutoffCache, userId)
+	return nil
+}
+
+// Unlike revoked state of token, cutoff can move forward, so it's always cached only for short time
+func (store *CachedRevocationStore) IsUserRevoked(ctx context.Context, userId uuid.UUID, issuedAt time.Time) (bool, error) {
+	store.mutex.RLock()
+	entry, exists := store.userCutoffCache[userId]
+	store.mutex.RUnlock()
+
+	if !exists || time.Now().After(entry.validUntil) {
+		notBefore, err := store.repository.GetUserCutoff(ctx, userId)
+		if err != nil {
+			return false, err
+		}
+
+		entry = userCutoffCacheEntry{notBefore: notBefore, validUntil: time.Now().Add(store.notRevokedCacheTtl)}
+
+		store.mutex.Lock()
+		store.userCutoffCache[userId] = entry
+		store.mutex.Unlock()
+	}
+
+	return issuedAt.Before(entry.notBefore), nil
+}
+
+// Periodically removes expired records from cache and database
+func (store *CachedRevocationStore) StartPruning() {
+	ctx := context.Background()
+
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		store.mutex.Lock()
+		now := time.Now()
+		for tokenId, entry := range store.cache {
+			if now.After(entry.validUntil) {
+				delete(store.cache, tokenId)
+			}
+		}
+		for userId, entry := range store.userCutoffCache {
+			if now.After(entry.validUntil) {
+				delete(store.userCutoffCache, userId)
+			}
+		}
+		store.mutex.Unlock()
+
+		err := store.repository.DeleteExpired(ctx)
+		if err != nil {
+			store.logger.Error("while pruning revoked tokens happened error", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrRoleNotFound = errors.New("role does not exists")
+var ErrRoleBuiltIn = errors.New("built-in role can't be deleted")
+
+// Admin role can't be deleted, otherwise nobody could manage roles anymore
+var builtInRoles = []string{entities.UserRoleAdmin}
+
+type RoleService interface {
+	GetAll() []*entities.Role
+
+	Get(name string) (*entities.Role, bool)
+
+	// Whether role can be chosen by user at registration
+	IsSelfAssignable(name string) bool
+
+	Add(ctx context.Context, role *entities.Role) error
+
+	// Returns false if role does not exists
+	Update(ctx context.Context, role *entities.Role) (bool, error)
+
+	// Returns ErrRoleBuiltIn or repositories.ErrRoleInUse if role can't be deleted and false if role does not exists
+	Delete(ctx context.Context, name string) (bool, error)
+
+	// Additional roles of user (without primary one)
+	GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error)
+
+	// Replaces additional roles of user, returns ErrRoleNotFound if any of roles does not exists
+	SetUserRoles(ctx context.Context, userId uuid.UUID, roleNames []string) error
+
+	// Returns all roles of user (primary one first) and union of their permissions
+	ResolveAccess(ctx context.Context, userId uuid.UUID, primaryRole string) ([]string, []string, error)
+}
+
+// RoleService which keeps definitions of roles in memory. Roles changed on other replica are picked up within a minute
+type CachedRoleService struct {
+	mutex sync.RWMutex
+
+	logger     *zap.Logger
+	repository repositories.RoleRepository
+
+	roles map[string]*entities.Role
+}
+
+func InitRoleService(repository repositories.RoleRepository, logger *zap.Logger) (*CachedRoleService, error) {
+	roleService := &CachedRoleService{
+		logger:     logger,
+		repository: repository,
+	}
+
+	err := roleService.reload(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return roleService, nil
+}
+
+// Periodically picks up roles changed by other replicas
+func (roleService *CachedRoleService) Start() {
+	ctx := context.Background()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := roleService.reload(ctx)
+		if err != nil {
+			roleService.logger.Error("while reloading roles happened error", zap.Error(err))
+		}
+	}
+}
+
+func (roleService *CachedRoleService) GetAll() []*entities.Role {
+	roleService.mutex.RLock()
+	defer roleService.mutex.RUnlock()
+
+	roles := make([]*entities.Role, 0, len(roleService.roles))
+	for _, role := range roleService.roles {
+		roles = append(roles, cloneRole(role))
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
+
+func (roleService *CachedRoleService) Get(name string) (*entities.Role, bool) {
+	roleService.mutex.RLock()
+	defer roleService.mutex.RUnlock()
+
+	role, exists := roleService.roles[name]
+	if !exists {
+		return nil, false
+	}
+
+	return cloneRole(role), true
+}
+
+func (roleService *CachedRoleService) IsSelfAssignable(name string) bool {
+	role, exists := roleService.Get(name)
+	return exists && role.IsSelfAssignable
+}
+
+func (roleService *CachedRoleService) Add(ctx context.Context, role *entities.Role) error {
+	err := roleService.repository.Add(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	return roleService.reload(ctx)
+}
+
+func (roleService *CachedRoleService) Update(ctx context.Context, role *entities.Role) (bool, error) {
+	isUpdated, err := roleService.repository.Update(ctx, role)
+	if err != nil || !isUpdated {
+		return isUpdated, err
+	}
+
+	return true, roleService.reload(ctx)
+}
+
+func (roleService *CachedRoleService) Delete(ctx context.Context, name string) (bool, error) {
+	if slices.Contains(builtInRoles, name) {
+		return false, ErrRoleBuiltIn
+	}
+
+	isDeleted, err := roleService.repository.Delete(ctx, name)
+	if err != nil || !isDeleted {
+		return isDeleted, err
+	}
+
+	return true, roleService.reload(ctx)
+}
+
+func (roleService *CachedRoleService) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	return roleService.repository.GetUserRoles(ctx, userId)
+}
+
+func (roleService *CachedRoleService) SetUserRoles(ctx context.Context, userId uuid.UUID, roleNames []string) error {
+	for _, roleName := range roleNames {
+		if _, exists := roleService.Get(roleName); !exists {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, roleName)
+		}
+	}
+
+	return roleService.repository.SetUserRoles(ctx, userId, roleNames)
+}
+
+func (roleService *CachedRoleService) ResolveAccess(ctx context.Context, userId uuid.UUID, primaryRole string) ([]string, []string, error) {
+	additionalRoles, err := roleService.repository.GetUserRoles(ctx, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roleNames := []string{primaryRole}
+	for _, roleName := range additionalRoles {
+		if !slices.Contains(roleNames, roleName) {
+			roleNames = append(roleNames, roleName)
+		}
+	}
+
+	roleService.mutex.RLock()
+	defer roleService.mutex.RUnlock()
+
+	permissions := []string{}
+	for _, roleName := range roleNames {
+		role, exists := roleService.roles[roleName]
+		if !exists {
+			continue
+		}
+
+		for _, permission := range role.Permissions {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	sort.Strings(permissions)
+	return roleNames, permissions, nil
+}
+
+func (roleService *CachedRoleService) reload(ctx context.Context) error {
+	roles, err := roleService.repository.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rolesByName := make(map[string]*entities.Role, len(roles))
+	for _, role := range roles {
+		rolesByName[role.Name] = role
+	}
+
+	roleService.mutex.Lock()
+	roleService.roles = rolesByName
+	roleService.mutex.Unlock()
+
+	return nil
+}
+
+// Cached roles are shared between requests, so callers get copies
+func cloneRole(role *entities.Role) *entities.Role {
+	clone := *role
+	clone.Permissions = slices.Clone(role.Permissions)
+	return &clone
+}
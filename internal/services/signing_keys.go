@@ -0,0 +1,158 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JSON Web Key (RFC 7517), only public parts of keys are exposed
+type JsonWebKey struct {
+	KeyId     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+
+	// EC and OKP (EdDSA)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys"`
+}
+
+var supportedSigningMethods = map[string]jwt.SigningMethod{
+	jwt.SigningMethodHS256.Alg(): jwt.SigningMethodHS256,
+	jwt.SigningMethodRS256.Alg(): jwt.SigningMethodRS256,
+	jwt.SigningMethodES256.Alg(): jwt.SigningMethodES256,
+	jwt.SigningMethodEdDSA.Alg(): jwt.SigningMethodEdDSA,
+}
+
+func isSymmetricAlgorithm(algorithm string) bool {
+	return algorithm == jwt.SigningMethodHS256.Alg()
+}
+
+// Loads private key from PEM file, its type must correspond to algorithm
+func loadPrivateKey(algorithm string, privateKeyPath string) (crypto.Signer, error) {
+	pemBytes, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("while reading private key file %s happened error: %w", privateKeyPath, err)
+	}
+
+	var privateKey crypto.Signer
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	case jwt.SigningMethodES256.Alg():
+		var ecdsaKey *ecdsa.PrivateKey
+		ecdsaKey, err = jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err == nil && ecdsaKey.Curve != elliptic.P256() {
+			err = errors.New("ES256 requires key on P-256 curve")
+		}
+		privateKey = ecdsaKey
+	case jwt.SigningMethodEdDSA.Alg():
+		var edKey crypto.PrivateKey
+		edKey, err = jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err == nil {
+			privateKey = edKey.(ed25519.PrivateKey)
+		}
+	default:
+		return nil, fmt.Errorf("algorithm %s doesn't use private keys", algorithm)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("while parsing private key file %s happened error: %w", privateKeyPath, err)
+	}
+
+	return privateKey, nil
+}
+
+// Generates new private key for algorithm (used when no key file was configured)
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodES256.Alg():
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("algorithm %s doesn't use private keys", algorithm)
+	}
+}
+
+func buildJsonWebKey(keyId string, algorithm string, publicKey crypto.PublicKey) (JsonWebKey, error) {
+	jsonWebKey := JsonWebKey{KeyId: keyId, Use: "sig", Algorithm: algorithm}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		jsonWebKey.KeyType = "RSA"
+		jsonWebKey.Modulus = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jsonWebKey.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// Coordinates must be padded to curve size (RFC 7518, section 6.2.1.2)
+		coordinateSize := (key.Curve.Params().BitSize + 7) / 8
+		jsonWebKey.KeyType = "EC"
+		jsonWebKey.Curve = key.Curve.Params().Name
+		jsonWebKey.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, coordinateSize)))
+		jsonWebKey.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, coordinateSize)))
+	case ed25519.PublicKey:
+		jsonWebKey.KeyType = "OKP"
+		jsonWebKey.Curve = "Ed25519"
+		jsonWebKey.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return JsonWebKey{}, fmt.Errorf("unsupported type of public key: %T", publicKey)
+	}
+
+	return jsonWebKey, nil
+}
+
+// JWK thumbprint (RFC 7638), used as "kid" of keys from config so it's the same on all replicas
+func computeKeyThumbprint(algorithm string, publicKey crypto.PublicKey) (string, error) {
+	jsonWebKey, err := buildJsonWebKey("", algorithm, publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Only required members, encoding/json sorts map keys lexicographically as RFC requires
+	requiredMembers := map[string]string{"kty": jsonWebKey.KeyType}
+	switch jsonWebKey.KeyType {
+	case "RSA":
+		requiredMembers["n"] = jsonWebKey.Modulus
+		requiredMembers["e"] = jsonWebKey.Exponent
+	case "EC":
+		requiredMembers["crv"] = jsonWebKey.Curve
+		requiredMembers["x"] = jsonWebKey.X
+		requiredMembers["y"] = jsonWebKey.Y
+	case "OKP":
+		requiredMembers["crv"] = jsonWebKey.Curve
+		requiredMembers["x"] = jsonWebKey.X
+	}
+
+	encodedMembers, err := json.Marshal(requiredMembers)
+	if err != nil {
+		return "", err
+	}
+
+	thumbprint := sha256.Sum256(encodedMembers)
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:]), nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	SmsCodeSourceSmsService  = "sms_service"
+	SmsCodeSourceAuthService = "auth_service"
+)
+
+type SmsCodeSender interface {
+	// Sends new sms code to phone number, previous code of phone number becomes invalid
+	Send(ctx context.Context, phoneNumber string) error
+}
+
+func InitSmsCodeSender(config SmsConfig, smsStorage SmsStorage, kafkaProducer KafkaProducer) (SmsCodeSender, error) {
+	switch config.CodeSource {
+	case SmsCodeSourceSmsService:
+		return &RemoteSmsCodeSender{kafkaProducer: kafkaProducer}, nil
+	case SmsCodeSourceAuthService:
+		return &LocalSmsCodeSender{smsStorage: smsStorage, kafkaProducer: kafkaProducer, codeLength: config.CodeLength}, nil
+	default:
+		return nil, fmt.Errorf("unknown sms code source: %s", config.CodeSource)
+	}
+}
+
+// Code is generated by SmsService and comes back via kafka (see KafkaConsumer)
+type RemoteSmsCodeSender struct {
+	kafkaProducer KafkaProducer
+}
+
+func (sender *RemoteSmsCodeSender) Send(ctx context.Context, phoneNumber string) error {
+	return sender.kafkaProducer.SendPhoneNumber(ctx, phoneNumber)
+}
+
+// Code is generated here and saved before sending, so plaintext code leaves service only in message to SmsService
+type LocalSmsCodeSender struct {
+	smsStorage    SmsStorage
+	kafkaProducer KafkaProducer
+	codeLength    int
+}
+
+func (sender *LocalSmsCodeSender) Send(ctx context.Context, phoneNumber string) error {
+	smsCode, err := generateSmsCode(sender.codeLength)
+	if err != nil {
+		return err
+	}
+
+	err = sender.smsStorage.Set(phoneNumber, smsCode)
+	if err != nil {
+		return err
+	}
+
+	return sender.kafkaProducer.SendSmsCode(ctx, phoneNumber, smsCode)
+}
+
+func generateSmsCode(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("while generating sms code happened error: %w", err)
+		}
+
+		digits[i] = byte('0' + digit.Int64())
+	}
+
+	return string(digits), nil
+}
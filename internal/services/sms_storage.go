@@ -1,62 +1,228 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
+
+	"go.uber.org/zap"
 )
 
+// Storage keeps only HMAC of codes, so leaked storage doesn't reveal codes
 type SmsStorage interface {
-	Get(phoneNumber string) (string, bool)
-	Set(phoneNumber string, code string)
+	Set(phoneNumber string, code string) error
+
+	// Checks code and deletes it on success, so code can be used only once.
+	// Every failed attempt is counted per phone number, so sending new code doesn't reset counter.
+	// After too many of them code is deleted and phone number is locked for a while.
+	// Counter is reset by accepted code or when lockout duration passes after last failure or end of lockout
+	Verify(phoneNumber string, code string) (SmsVerificationResult, error)
+
+	// Returns end of lockout of phone number, zero time if phone number is not locked
+	LockedUntil(phoneNumber string) (time.Time, error)
+
+	// Periodically removes expired codes, lockouts and counters of failed attempts
+	StartCleanup()
+}
+
+type SmsVerificationStatus int
+
+const (
+	SmsCodeAccepted SmsVerificationStatus = iota
+	SmsCodeNotRequested
+	SmsCodeInvalid
+	SmsCodeLocked
+)
+
+type SmsVerificationResult struct {
+	Status SmsVerificationStatus
+
+	// Set for SmsCodeInvalid
+	AttemptsLeft int
+
+	// Set for SmsCodeLocked
+	LockedUntil time.Time
+}
+
+const (
+	SmsStorageMemory   = "memory"
+	SmsStoragePostgres = "postgres"
+)
+
+type smsVerificationPolicy struct {
+	codeLifetime    time.Duration
+	maxAttempts     int
+	lockoutDuration time.Duration
+
+	// Key of HMAC for stored codes
+	hashKey []byte
+}
+
+// Memory storage is visible only to replica which received code, so with several replicas postgres storage must be used
+func InitSmsStorage(config SmsConfig, secretKey string, connection *sql.DB, logger *zap.Logger) (SmsStorage, error) {
+	hashKey, err := resolveSmsCodeHashKey(config, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := smsVerificationPolicy{
+		codeLifetime:    time.Duration(config.CodeTtlSeconds) * time.Second,
+		maxAttempts:     config.MaxAttempts,
+		lockoutDuration: time.Duration(config.LockoutMinutes) * time.Minute,
+		hashKey:         hashKey,
+	}
+
+	switch config.Storage {
+	case SmsStorageMemory:
+		return NewSmsStorage(policy), nil
+	case SmsStoragePostgres:
+		return NewPgSmsStorage(connection, policy, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown sms storage: %s", config.Storage)
+	}
+}
+
+// Without configured key memory storage uses random one, codes don't outlive process anyway
+func resolveSmsCodeHashKey(config SmsConfig, secretKey string) ([]byte, error) {
+	if config.CodeHashKey != "" {
+		return []byte(config.CodeHashKey), nil
+	}
+
+	if secretKey != "" {
+		return []byte(secretKey), nil
+	}
+
+	if config.Storage != SmsStorageMemory {
+		return nil, errors.New("sms code hash key is required for shared sms storage")
+	}
+
+	hashKey := make([]byte, 32)
+	_, err := rand.Read(hashKey)
+	if err != nil {
+		return nil, fmt.Errorf("while generating sms code hash key happened error: %w", err)
+	}
+
+	return hashKey, nil
+}
+
+// Phone number is part of hashed message, so equal codes of different phone numbers have different hashes
+func (policy smsVerificationPolicy) hashCode(phoneNumber string, code string) string {
+	mac := hmac.New(sha256.New, policy.hashKey)
+	mac.Write([]byte(phoneNumber + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (policy smsVerificationPolicy) isCodeMatching(phoneNumber string, code string, storedHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(policy.hashCode(phoneNumber, code)), []byte(storedHash)) == 1
 }
 
 type ThreadSafeSmsStorage struct {
 	mutex sync.RWMutex
 
-	// format: phone_number: {code: "9876", expiresAt: time.Time}
+	policy smsVerificationPolicy
+
+	// format: phone_number: {codeHash: "hmac hex", expiresAt: time.Time}
 	storage map[string]smsEntry
+
+	// format: phone_number: locked_until
+	lockouts map[string]time.Time
+
+	// format: phone_number: {count: 0, expiresAt: time.Time}
+	failures map[string]smsFailures
 }
 
 type smsEntry struct {
-	code      string
+	codeHash  string
+	expiresAt time.Time
+}
+
+type smsFailures struct {
+	count     int
 	expiresAt time.Time
 }
 
-func NewSmsStorage() *ThreadSafeSmsStorage {
+func NewSmsStorage(policy smsVerificationPolicy) *ThreadSafeSmsStorage {
 	return &ThreadSafeSmsStorage{
-		storage: make(map[string]smsEntry),
+		policy:   policy,
+		storage:  make(map[string]smsEntry),
+		lockouts: make(map[string]time.Time),
+		failures: make(map[string]smsFailures),
 	}
 }
 
-func (s *ThreadSafeSmsStorage) Set(phoneNumber, code string) {
+func (s *ThreadSafeSmsStorage) Set(phoneNumber, code string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.storage[phoneNumber] = smsEntry{
-		code:      code,
-		expiresAt: time.Now().Add(3 * time.Minute),
+		codeHash:  s.policy.hashCode(phoneNumber, code),
+		expiresAt: time.Now().Add(s.policy.codeLifetime),
 	}
+
+	return nil
 }
 
-func (s *ThreadSafeSmsStorage) Get(phoneNumber string) (string, bool) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+func (s *ThreadSafeSmsStorage) Verify(phoneNumber string, code string) (SmsVerificationResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if lockedUntil, isLocked := s.lockouts[phoneNumber]; isLocked && now.Before(lockedUntil) {
+		return SmsVerificationResult{Status: SmsCodeLocked, LockedUntil: lockedUntil}, nil
+	}
 
 	entry, exists := s.storage[phoneNumber]
-	if !exists {
-		return "", false
+	if !exists || now.After(entry.expiresAt) {
+		return SmsVerificationResult{Status: SmsCodeNotRequested}, nil
 	}
 
-	if time.Now().After(entry.expiresAt) {
-		s.cleanup()
-		return "", false
+	if s.policy.isCodeMatching(phoneNumber, code, entry.codeHash) {
+		delete(s.storage, phoneNumber)
+		delete(s.failures, phoneNumber)
+		return SmsVerificationResult{Status: SmsCodeAccepted}, nil
 	}
 
-	return entry.code, true
+	failures := s.failures[phoneNumber]
+	if now.After(failures.expiresAt) {
+		failures = smsFailures{}
+	}
+
+	failures.count++
+	failures.expiresAt = now.Add(s.policy.lockoutDuration)
+	s.failures[phoneNumber] = failures
+
+	if failures.count >= s.policy.maxAttempts {
+		delete(s.storage, phoneNumber)
+
+		// Counter expires together with lockout, so after lockout all attempts are available again
+		s.lockouts[phoneNumber] = failures.expiresAt
+		return SmsVerificationResult{Status: SmsCodeLocked, LockedUntil: failures.expiresAt}, nil
+	}
+
+	return SmsVerificationResult{Status: SmsCodeInvalid, AttemptsLeft: s.policy.maxAttempts - failures.count}, nil
+}
+
+func (s *ThreadSafeSmsStorage) LockedUntil(phoneNumber string) (time.Time, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	lockedUntil, isLocked := s.lockouts[phoneNumber]
+	if !isLocked || time.Now().After(lockedUntil) {
+		return time.Time{}, nil
+	}
+
+	return lockedUntil, nil
 }
 
 // cleaning expired notes (not so fast, but sometimes)
-func (s *ThreadSafeSmsStorage) cleanup() {
+func (s *ThreadSafeSmsStorage) StartCleanup() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
@@ -68,6 +234,179 @@ func (s *ThreadSafeSmsStorage) cleanup() {
 				delete(s.storage, phone)
 			}
 		}
+		for phone, lockedUntil := range s.lockouts {
+			if now.After(lockedUntil) {
+				delete(s.lockouts, phone)
+			}
+		}
+		for phone, failures := range s.failures {
+			if now.After(failures.expiresAt) {
+				delete(s.failures, phone)
+			}
+		}
 		s.mutex.Unlock()
 	}
 }
+
+// SmsStorage shared by all replicas, codes are kept in table sms_codes, lockouts in sms_lockouts
+// and counters of failed attempts in sms_failed_attempts
+type PgSmsStorage struct {
+	connection *sql.DB
+	policy     smsVerificationPolicy
+	logger     *zap.Logger
+}
+
+func NewPgSmsStorage(connection *sql.DB, policy smsVerificationPolicy, logger *zap.Logger) *PgSmsStorage {
+	return &PgSmsStorage{
+		connection: connection,
+		policy:     policy,
+		logger:     logger,
+	}
+}
+
+func (s *PgSmsStorage) Set(phoneNumber, code string) error {
+	setCodeQuery := `INSERT INTO sms_codes (phone_number, code, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (phone_number) DO UPDATE SET code = EXCLUDED.code, expires_at = EXCLUDED.expires_at`
+
+	_, err := s.connection.Exec(setCodeQuery, phoneNumber, s.policy.hashCode(phoneNumber, code), time.Now().Add(s.policy.codeLifetime))
+	if err != nil {
+		return fmt.Errorf("while saving sms code for %s happened error: %w", phoneNumber, err)
+	}
+
+	return nil
+}
+
+func (s *PgSmsStorage) Verify(phoneNumber string, code string) (SmsVerificationResult, error) {
+	transaction, err := s.connection.Begin()
+	if err != nil {
+		return SmsVerificationResult{}, fmt.Errorf("while verifying sms code for %s happened error: %w", phoneNumber, err)
+	}
+	defer transaction.Rollback()
+
+	result, err := s.verifyInTransaction(transaction, phoneNumber, code)
+	if err != nil {
+		return SmsVerificationResult{}, fmt.Errorf("while verifying sms code for %s happened error: %w", phoneNumber, err)
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return SmsVerificationResult{}, fmt.Errorf("while verifying sms code for %s happened error: %w", phoneNumber, err)
+	}
+
+	return result, nil
+}
+
+func (s *PgSmsStorage) verifyInTransaction(transaction *sql.Tx, phoneNumber string, code string) (SmsVerificationResult, error) {
+	now := time.Now()
+
+	var lockedUntil time.Time
+	lockoutQuery := "SELECT locked_until FROM sms_lockouts WHERE phone_number = $1 AND locked_until > $2"
+	err := transaction.QueryRow(lockoutQuery, phoneNumber, now).Scan(&lockedUntil)
+	if err == nil {
+		return SmsVerificationResult{Status: SmsCodeLocked, LockedUntil: lockedUntil}, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return SmsVerificationResult{}, err
+	}
+
+	// Row is locked, so concurrent attempts are counted one after another
+	var storedHash string
+	codeQuery := "SELECT code FROM sms_codes WHERE phone_number = $1 AND expires_at > $2 FOR UPDATE"
+	err = transaction.QueryRow(codeQuery, phoneNumber, now).Scan(&storedHash)
+	if err == sql.ErrNoRows {
+		return SmsVerificationResult{Status: SmsCodeNotRequested}, nil
+	}
+
+	if err != nil {
+		return SmsVerificationResult{}, err
+	}
+
+	if s.policy.isCodeMatching(phoneNumber, code, storedHash) {
+		_, err = transaction.Exec("DELETE FROM sms_codes WHERE phone_number = $1", phoneNumber)
+		if err != nil {
+			return SmsVerificationResult{}, err
+		}
+
+		_, err = transaction.Exec("DELETE FROM sms_failed_attempts WHERE phone_number = $1", phoneNumber)
+		if err != nil {
+			return SmsVerificationResult{}, err
+		}
+
+		return SmsVerificationResult{Status: SmsCodeAccepted}, nil
+	}
+
+	// Expired counter starts again from one failure
+	var failedAttempts int
+	countFailureQuery := `INSERT INTO sms_failed_attempts (phone_number, failed_attempts, expires_at) VALUES ($1, 1, $3)
+		ON CONFLICT (phone_number) DO UPDATE SET
+			failed_attempts = CASE WHEN sms_failed_attempts.expires_at > $2 THEN sms_failed_attempts.failed_attempts + 1 ELSE 1 END,
+			expires_at = EXCLUDED.expires_at
+		RETURNING failed_attempts`
+
+	lockedUntil = now.Add(s.policy.lockoutDuration)
+	err = transaction.QueryRow(countFailureQuery, phoneNumber, now, lockedUntil).Scan(&failedAttempts)
+	if err != nil {
+		return SmsVerificationResult{}, err
+	}
+
+	// Counter expires together with lockout, so after lockout all attempts are available again
+	if failedAttempts >= s.policy.maxAttempts {
+		_, err = transaction.Exec("DELETE FROM sms_codes WHERE phone_number = $1", phoneNumber)
+		if err != nil {
+			return SmsVerificationResult{}, err
+		}
+
+		lockQuery := `INSERT INTO sms_lockouts (phone_number, locked_until) VALUES ($1, $2)
+			ON CONFLICT (phone_number) DO UPDATE SET locked_until = EXCLUDED.locked_until`
+
+		_, err = transaction.Exec(lockQuery, phoneNumber, lockedUntil)
+		if err != nil {
+			return SmsVerificationResult{}, err
+		}
+
+		return SmsVerificationResult{Status: SmsCodeLocked, LockedUntil: lockedUntil}, nil
+	}
+
+	return SmsVerificationResult{Status: SmsCodeInvalid, AttemptsLeft: s.policy.maxAttempts - failedAttempts}, nil
+}
+
+func (s *PgSmsStorage) LockedUntil(phoneNumber string) (time.Time, error) {
+	var lockedUntil time.Time
+	lockoutQuery := "SELECT locked_until FROM sms_lockouts WHERE phone_number = $1 AND locked_until > $2"
+
+	err := s.connection.QueryRow(lockoutQuery, phoneNumber, time.Now()).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("while retrieving lockout of %s happened error: %w", phoneNumber, err)
+	}
+
+	return lockedUntil, nil
+}
+
+func (s *PgSmsStorage) StartCleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+
+		_, err := s.connection.Exec("DELETE FROM sms_codes WHERE expires_at <= $1", now)
+		if err != nil {
+			s.logger.Error("while deleting expired sms codes happened error", zap.Error(err))
+		}
+
+		_, err = s.connection.Exec("DELETE FROM sms_lockouts WHERE locked_until <= $1", now)
+		if err != nil {
+			s.logger.Error("while deleting expired sms lockouts happened error", zap.Error(err))
+		}
+
+		_, err = s.connection.Exec("DELETE FROM sms_failed_attempts WHERE expires_at <= $1", now)
+		if err != nil {
+			s.logger.Error("while deleting expired sms failed attempts happened error", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+const testSmsMaxAttempts = 3
+
+func newTestSmsStorage(codeLifetime time.Duration, lockoutDuration time.Duration) *ThreadSafeSmsStorage {
+	return NewSmsStorage(smsVerificationPolicy{
+		codeLifetime:    codeLifetime,
+		maxAttempts:     testSmsMaxAttempts,
+		lockoutDuration: lockoutDuration,
+		hashKey:         []byte("test-key"),
+	})
+}
+
+func setSmsCode(t *testing.T, storage SmsStorage, phoneNumber string, code string) {
+	t.Helper()
+
+	err := storage.Set(phoneNumber, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verifySmsCode(t *testing.T, storage SmsStorage, phoneNumber string, code string) SmsVerificationResult {
+	t.Helper()
+
+	result, err := storage.Verify(phoneNumber, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestSmsStorageAcceptsCodeOnce(t *testing.T) {
+	storage := newTestSmsStorage(time.Minute, time.Minute)
+	setSmsCode(t, storage, "+79123456789", "1234")
+
+	if result := verifySmsCode(t, storage, "+79001112233", "1234"); result.Status != SmsCodeNotRequested {
+		t.Fatalf("code of another phone number is accepted: %+v", result)
+	}
+
+	if result := verifySmsCode(t, storage, "+79123456789", "1234"); result.Status != SmsCodeAccepted {
+		t.Fatalf("expected code to be accepted, got %+v", result)
+	}
+
+	if result := verifySmsCode(t, storage, "+79123456789", "1234"); result.Status != SmsCodeNotRequested {
+		t.Fatalf("expected used code to be deleted, got %+v", result)
+	}
+}
+
+func TestSmsStorageRejectsExpiredCode(t *testing.T) {
+	storage := newTestSmsStorage(10*time.Millisecond, time.Minute)
+	setSmsCode(t, storage, "+79123456789", "1234")
+
+	time.Sleep(20 * time.Millisecond)
+
+	if result := verifySmsCode(t, storage, "+79123456789", "1234"); result.Status != SmsCodeNotRequested {
+		t.Fatalf("expected expired code to be rejected, got %+v", result)
+	}
+}
+
+func TestSmsStorageLocksPhoneNumberAfterMaxAttempts(t *testing.T) {
+	storage := newTestSmsStorage(time.Minute, time.Minute)
+	setSmsCode(t, storage, "+79123456789", "1234")
+
+	for attemptsLeft := testSmsMaxAttempts - 1; attemptsLeft > 0; attemptsLeft-- {
+		result := verifySmsCode(t, storage, "+79123456789", "0000")
+		if result.Status != SmsCodeInvalid || result.AttemptsLeft != attemptsLeft {
+			t.Fatalf("expected invalid code with %d attempts left, got %+v", attemptsLeft, result)
+		}
+	}
+
+	result := verifySmsCode(t, storage, "+79123456789", "0000")
+	if result.Status != SmsCodeLocked || result.LockedUntil.IsZero() {
+		t.Fatalf("expected phone number to be locked, got %+v", result)
+	}
+
+	lockedUntil, err := storage.LockedUntil("+79123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !lockedUntil.Equal(result.LockedUntil) {
+		t.Fatalf("expected lockout till %v, got %v", result.LockedUntil, lockedUntil)
+	}
+
+	// Even right code is rejected during lockout
+	setSmsCode(t, storage, "+79123456789", "1234")
+	if result := verifySmsCode(t, storage, "+79123456789", "1234"); result.Status != SmsCodeLocked {
+		t.Fatalf("expected locked phone number to reject code, got %+v", result)
+	}
+
+	if lockedUntil, _ := storage.LockedUntil("+79001112233"); !lockedUntil.IsZero() {
+		t.Fatalf("another phone number is locked till %v", lockedUntil)
+	}
+}
+
+func TestSmsStorageKeepsFailedAttemptsAfterResend(t *testing.T) {
+	storage := newTestSmsStorage(time.Minute, time.Minute)
+
+	for range testSmsMaxAttempts - 1 {
+		setSmsCode(t, storage, "+79123456789", "1234")
+		verifySmsCode(t, storage, "+79123456789", "0000")
+	}
+
+	setSmsCode(t, storage, "+79123456789", "1234")
+	if result := verifySmsCode(t, storage, "+79123456789", "0000"); result.Status != SmsCodeLocked {
+		t.Fatalf("expected new code not to reset failed attempts, got %+v", result)
+	}
+}
+
+func TestSmsStorageResetsFailedAttemptsAfterAcceptedCode(t *testing.T) {
+	storage := newTestSmsStorage(time.Minute, time.Minute)
+	setSmsCode(t, storage, "+79123456789", "1234")
+
+	verifySmsCode(t, storage, "+79123456789", "0000")
+	if result := verifySmsCode(t, storage, "+79123456789", "1234"); result.Status != SmsCodeAccepted {
+		t.Fatalf("expected code to be accepted, got %+v", result)
+	}
+
+	setSmsCode(t, storage, "+79123456789", "5678")
+	result := verifySmsCode(t, storage, "+79123456789", "0000")
+	if result.Status != SmsCodeInvalid || result.AttemptsLeft != testSmsMaxAttempts-1 {
+		t.Fatalf("expected failed attempts to be reset, got %+v", result)
+	}
+}
+
+func TestSmsStorageResetsFailedAttemptsAfterLockout(t *testing.T) {
+	storage := newTestSmsStorage(time.Minute, 30*time.Millisecond)
+	setSmsCode(t, storage, "+79123456789", "1234")
+
+	for range testSmsMaxAttempts {
+		verifySmsCode(t, storage, "+79123456789", "0000")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	lockedUntil, err := storage.LockedUntil("+79123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !lockedUntil.IsZero() {
+		t.Fatalf("expected lockout to be over, phone number is locked till %v", lockedUntil)
+	}
+
+	setSmsCode(t, storage, "+79123456789", "1234")
+	result := verifySmsCode(t, storage, "+79123456789", "0000")
+	if result.Status != SmsCodeInvalid || result.AttemptsLeft != testSmsMaxAttempts-1 {
+		t.Fatalf("expected all attempts to be available after lockout, got %+v", result)
+	}
+}
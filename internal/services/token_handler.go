@@ -1,36 +1,108 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+var ErrTokenInvalid = errors.New("token is invalid")
+var ErrTokenRevoked = errors.New("token is revoked")
+var ErrUserNotActive = errors.New("user is suspended, banned or deleted")
+
 type TokenHandler interface {
-	GenerateToken(userID uuid.UUID, userRole string) (string, error)
-	ValidateToken(token string) (bool, error)
+	// Token carries primary role of user in "user_role" claim and all its roles and permissions in "roles" and "permissions" claims
+	GenerateToken(ctx context.Context, userID uuid.UUID, userRole string) (string, error)
+	ValidateToken(ctx context.Context, token string) (bool, error)
+
+	// Validates token (signature, expiration, revocation and status of user if tokens.check_user_status is enabled) and returns its claims
+	ParseToken(ctx context.Context, token string) (*TokenClaims, error)
+
+	// Adds token to denylist, so it's not valid anymore. Already expired token is ignored
+	RevokeToken(ctx context.Context, token string) error
+
+	// Revokes all access tokens of user issued before this moment (e.g. after change of phone number)
+	RevokeUserTokens(ctx context.Context, userId uuid.UUID) error
+
+	// Public keys for local verification of tokens by other services (empty for HS256)
+	GetJsonWebKeySet() JsonWebKeySet
+}
+
+type TokenClaims struct {
+	UserId   uuid.UUID `json:"user_id"`
+	UserRole string    `json:"user_role"`
+
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+
+	jwt.RegisteredClaims
 }
 
 type JwtTokenHandler struct {
-	secretKey string
+	keyring           Keyring
+	revocationStore   RevocationStore
+	userStatusChecker UserStatusChecker
+	checkUserStatus   bool
+	roleService       RoleService
+
+	issuer    string
+	audiences []string
+	lifetime  time.Duration
 }
 
-func InitTokenHandler(secretKey string) (*JwtTokenHandler, error) {
-	tokenHandler := JwtTokenHandler{secretKey}
+func InitTokenHandler(keyring Keyring, revocationStore RevocationStore, userStatusChecker UserStatusChecker, roleService RoleService, config TokenConfig) (*JwtTokenHandler, error) {
+	if keyring.Current() == nil {
+		return nil, errors.New("keyring doesn't have key for signing tokens")
+	}
+
+	tokenHandler := JwtTokenHandler{
+		keyring:           keyring,
+		revocationStore:   revocationStore,
+		userStatusChecker: userStatusChecker,
+		checkUserStatus:   config.CheckUserStatus,
+		roleService:       roleService,
+		issuer:            config.Issuer,
+		audiences:         config.Audiences,
+		lifetime:          config.AccessTokenLifetime(),
+	}
+
 	return &tokenHandler, nil
 }
 
-func (tokenHandler *JwtTokenHandler) GenerateToken(userID uuid.UUID, userRole string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":   userID,
-		"user_role": userRole,
-		"exp":       time.Now().Add(time.Hour * 24).Unix(), // Срок действия — 24 часа
+func (tokenHandler *JwtTokenHandler) GenerateToken(ctx context.Context, userID uuid.UUID, userRole string) (string, error) {
+	roles, permissions, err := tokenHandler.roleService.ResolveAccess(ctx, userID, userRole)
+	if err != nil {
+		return "", fmt.Errorf("while resolving roles of user %s happened error: %w", userID, err)
+	}
+
+	now := time.Now()
+	claims := &TokenClaims{
+		UserId:      userID,
+		UserRole:    userRole,
+		Roles:       roles,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    tokenHandler.issuer,
+			Subject:   userID.String(),
+			Audience:  tokenHandler.audiences,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenHandler.lifetime)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedString, err := token.SignedString([]byte(tokenHandler.secretKey))
+	signingKey := tokenHandler.keyring.Current()
+
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.Id
+
+	signedString, err := token.SignedString(signingKey.PrivateKey)
 	if err != nil {
 		fmt.Println(err)
 		return "", err
@@ -39,18 +111,184 @@ func (tokenHandler *JwtTokenHandler) GenerateToken(userID uuid.UUID, userRole st
 	return signedString, nil
 }
 
-func (tokenHandler *JwtTokenHandler) ValidateToken(token string) (bool, error) {
-	parseResult, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenHandler.secretKey), nil
-	})
-
+func (tokenHandler *JwtTokenHandler) ValidateToken(ctx context.Context, token string) (bool, error) {
+	_, err := tokenHandler.ParseToken(ctx, token)
 	if err != nil {
 		return false, err
 	}
 
+	return true, nil
+}
+
+func (tokenHandler *JwtTokenHandler) ParseToken(ctx context.Context, token string) (*TokenClaims, error) {
+	claims, err := tokenHandler.parseClaims(token)
+	if err != nil {
+		return nil, err
+	}
+
+	isUserRevoked, err := tokenHandler.isRevokedWithUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if isUserRevoked {
+		return nil, ErrTokenRevoked
+	}
+
+	if tokenHandler.checkUserStatus {
+		isActive, err := tokenHandler.userStatusChecker.IsActive(ctx, claims.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isActive {
+			return nil, ErrUserNotActive
+		}
+	}
+
+	// Tokens issued before denylist appeared don't have "jti" and can't be revoked
+	if claims.ID == "" {
+		return claims, nil
+	}
+
+	isRevoked, err := tokenHandler.revocationStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isRevoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+func (tokenHandler *JwtTokenHandler) RevokeToken(ctx context.Context, token string) error {
+	claims, err := tokenHandler.parseClaims(token)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: token doesn't have jti claim", ErrTokenInvalid)
+	}
+
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: token doesn't have exp claim", ErrTokenInvalid)
+	}
+
+	return tokenHandler.revocationStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+func (tokenHandler *JwtTokenHandler) RevokeUserTokens(ctx context.Context, userId uuid.UUID) error {
+	// "iat" has precision of seconds, so cutoff is rounded down, otherwise tokens issued
+	// in the same second right after revocation would be revoked too
+	return tokenHandler.revocationStore.RevokeUser(ctx, userId, time.Now().Truncate(time.Second))
+}
+
+func (tokenHandler *JwtTokenHandler) GetJsonWebKeySet() JsonWebKeySet {
+	jsonWebKeySet := JsonWebKeySet{Keys: []JsonWebKey{}}
+
+	for _, key := range tokenHandler.keyring.VerificationKeys() {
+		if isSymmetricAlgorithm(key.Method.Alg()) {
+			continue
+		}
+
+		jsonWebKey, err := buildJsonWebKey(key.Id, key.Method.Alg(), key.PublicKey)
+		if err != nil {
+			continue
+		}
+
+		jsonWebKeySet.Keys = append(jsonWebKeySet.Keys, jsonWebKey)
+	}
+
+	return jsonWebKeySet
+}
+
+// Checks signature, algorithm, issuer, audience and time claims of token.
+// Tokens issued before keyring appeared have neither "kid" nor issuer and audience, they are accepted
+// with legacy key without these checks until they expire
+func (tokenHandler *JwtTokenHandler) parseClaims(token string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	parseResult, err := jwt.ParseWithClaims(token, claims, tokenHandler.findVerificationKey,
+		jwt.WithValidMethods(tokenHandler.validMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt())
+
+	if err != nil {
+		return nil, err
+	}
+
 	if !parseResult.Valid {
-		return false, nil
+		return nil, ErrTokenInvalid
 	}
 
-	return true, nil
+	if _, hasKeyId := parseResult.Header["kid"]; !hasKeyId {
+		return claims, nil
+	}
+
+	if claims.Issuer != tokenHandler.issuer {
+		return nil, fmt.Errorf("%w: %w", ErrTokenInvalid, jwt.ErrTokenInvalidIssuer)
+	}
+
+	isOurAudience := slices.ContainsFunc(claims.Audience, func(audience string) bool {
+		return slices.Contains(tokenHandler.audiences, audience)
+	})
+
+	if !isOurAudience {
+		return nil, fmt.Errorf("%w: token is minted for another audience", ErrTokenInvalid)
+	}
+
+	return claims, nil
+}
+
+func (tokenHandler *JwtTokenHandler) isRevokedWithUser(ctx context.Context, claims *TokenClaims) (bool, error) {
+	// Token without "iat" can't be compared with cutoff, so it's revoked if there is any cutoff
+	issuedAt := time.Time{}
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	return tokenHandler.revocationStore.IsUserRevoked(ctx, claims.UserId, issuedAt)
+}
+
+// Algorithm of current key and algorithm of legacy key, tokens signed by other algorithms are rejected
+func (tokenHandler *JwtTokenHandler) validMethods() []string {
+	validMethods := []string{tokenHandler.keyring.Current().Method.Alg()}
+
+	legacyKey := tokenHandler.keyring.Legacy()
+	if legacyKey != nil && !slices.Contains(validMethods, legacyKey.Method.Alg()) {
+		validMethods = append(validMethods, legacyKey.Method.Alg())
+	}
+
+	return validMethods
+}
+
+func (tokenHandler *JwtTokenHandler) findVerificationKey(token *jwt.Token) (interface{}, error) {
+	keyId, hasKeyId := token.Header["kid"].(string)
+
+	// Tokens issued before keyring appeared don't have "kid"
+	if !hasKeyId {
+		legacyKey := tokenHandler.keyring.Legacy()
+		if legacyKey == nil {
+			return nil, errors.New("token doesn't have kid header")
+		}
+
+		if token.Method.Alg() != legacyKey.Method.Alg() {
+			return nil, fmt.Errorf("token without kid is signed by %s, not by algorithm of legacy key", token.Method.Alg())
+		}
+
+		return legacyKey.PublicKey, nil
+	}
+
+	key, exists := tokenHandler.keyring.Get(keyId)
+	if !exists {
+		return nil, fmt.Errorf("unknown signing key: %s", keyId)
+	}
+
+	return key.PublicKey, nil
 }
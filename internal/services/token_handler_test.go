@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/phone"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const testSecretKey = "test-secret-key"
+
+func newTestTokenHandler(t *testing.T) *JwtTokenHandler {
+	t.Helper()
+
+	phoneParser, err := phone.NewParser([]string{"7"}, "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := repositories.NewInMemoryStorage(phoneParser)
+	config := TokenConfig{Issuer: "auth-service", Audiences: []string{"webchads"}, AccessTokenTtlMinutes: 60, Algorithm: "HS256"}
+
+	keyring, err := InitKeyring(testSecretKey, config, storage.SigningKeys, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roleService, err := InitRoleService(storage.Roles, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenHandler, err := InitTokenHandler(keyring, NewRevocationStore(storage.RevokedTokens, config, zap.NewNop()),
+		NewUserStatusChecker(storage.Users, config, zap.NewNop()), roleService, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenHandler
+}
+
+// Token in format issued before keyring, issuer and audiences appeared
+func signLegacyToken(t *testing.T, userId uuid.UUID, expiresAt time.Time) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":   userId,
+		"user_role": "Sportsman",
+		"exp":       expiresAt.Unix(),
+	})
+
+	signedString, err := token.SignedString([]byte(testSecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signedString
+}
+
+func TestTokenHandlerParsesIssuedToken(t *testing.T) {
+	tokenHandler := newTestTokenHandler(t)
+	userId := uuid.New()
+
+	token, err := tokenHandler.GenerateToken(context.Background(), userId, "Sportsman")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := tokenHandler.ParseToken(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.UserId != userId || claims.Issuer != "auth-service" || claims.ID == "" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestTokenHandlerAcceptsLegacyTokenUntilItExpires(t *testing.T) {
+	tokenHandler := newTestTokenHandler(t)
+	userId := uuid.New()
+
+	claims, err := tokenHandler.ParseToken(context.Background(), signLegacyToken(t, userId, time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("legacy token is rejected: %v", err)
+	}
+
+	if claims.UserId != userId || claims.UserRole != "Sportsman" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	_, err = tokenHandler.ParseToken(context.Background(), signLegacyToken(t, userId, time.Now().Add(-time.Minute)))
+	if !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("expected expired legacy token to be rejected, got %v", err)
+	}
+}
+
+func TestTokenHandlerChecksIssuerAndAudienceOfTokenWithKeyId(t *testing.T) {
+	tokenHandler := newTestTokenHandler(t)
+	signingKey := tokenHandler.keyring.Current()
+
+	testCases := []struct {
+		name     string
+		issuer   string
+		audience string
+	}{
+		{name: "another issuer", issuer: "another-service", audience: "webchads"},
+		{name: "no issuer", audience: "webchads"},
+		{name: "another audience", issuer: "auth-service", audience: "another-audience"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(signingKey.Method, &TokenClaims{
+				UserId: uuid.New(),
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        uuid.NewString(),
+					Issuer:    testCase.issuer,
+					Audience:  jwt.ClaimStrings{testCase.audience},
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+			})
+			token.Header["kid"] = signingKey.Id
+
+			signedString, err := token.SignedString(signingKey.PrivateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = tokenHandler.ParseToken(context.Background(), signedString)
+			if !errors.Is(err, ErrTokenInvalid) {
+				t.Fatalf("expected ErrTokenInvalid, got %v", err)
+			}
+		})
+	}
+}
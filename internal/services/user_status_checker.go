@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type UserStatusChecker interface {
+	// Returns false if user is suspended, banned, deleted or does not exists
+	IsActive(ctx context.Context, userId uuid.UUID) (bool, error)
+
+	// Drops cached status of user, so its change is visible on this replica at once
+	Forget(userId uuid.UUID)
+}
+
+// UserStatusChecker with in-process cache, status changed on other replica is picked up with delay of at most cache lifetime
+type CachedUserStatusChecker struct {
+	mutex sync.RWMutex
+
+	logger     *zap.Logger
+	repository repositories.UserRepository
+
+	cacheTtl time.Duration
+
+	// format: user_id: {isActive: true, validUntil: time.Time}
+	cache map[uuid.UUID]userStatusCacheEntry
+}
+
+type userStatusCacheEntry struct {
+	isActive   bool
+	validUntil time.Time
+}
+
+func NewUserStatusChecker(repository repositories.UserRepository, config TokenConfig, logger *zap.Logger) *CachedUserStatusChecker {
+	return &CachedUserStatusChecker{
+		logger:     logger,
+		repository: repository,
+		cacheTtl:   time.Duration(config.UserStatusCacheSeconds) * time.Second,
+		cache:      make(map[uuid.UUID]userStatusCacheEntry),
+	}
+}
+
+func (checker *CachedUserStatusChecker) IsActive(ctx context.Context, userId uuid.UUID) (bool, error) {
+	checker.mutex.RLock()
+	entry, exists := checker.cache[userId]
+	checker.mutex.RUnlock()
+
+	if exists && time.Now().Before(entry.validUntil) {
+		return entry.isActive, nil
+	}
+
+	isActive := false
+	user, err := checker.repository.GetById(ctx, userId)
+	if err == nil {
+		isActive = user.Status == entities.UserStatusActive
+	} else if !errors.Is(err, repositories.ErrUserNotFound) {
+		return false, err
+	}
+
+	checker.mutex.Lock()
+	checker.cache[userId] = userStatusCacheEntry{isActive: isActive, validUntil: time.Now().Add(checker.cacheTtl)}
+	checker.mutex.Unlock()
+
+	return isActive, nil
+}
+
+func (checker *CachedUserStatusChecker) Forget(userId uuid.UUID) {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	delete(checker.cache, userId)
+}
+
+// Periodically removes expired records from cache
+func (checker *CachedUserStatusChecker) StartPruning() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		checker.mutex.Lock()
+		now := time.Now()
+		for userId, entry := range checker.cache {
+			if now.After(entry.validUntil) {
+				delete(checker.cache, userId)
+			}
+		}
+		checker.mutex.Unlock()
+	}
+}
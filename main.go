@@ -1,17 +1,25 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/WebChads/AuthService/docs"
 	"github.com/WebChads/AuthService/internal/database"
 	"github.com/WebChads/AuthService/internal/database/repositories"
+	"github.com/WebChads/AuthService/internal/middlewares"
+	"github.com/WebChads/AuthService/internal/models/entities"
+	"github.com/WebChads/AuthService/internal/phone"
 	"github.com/WebChads/AuthService/internal/routers"
 	"github.com/WebChads/AuthService/internal/services"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.uber.org/zap"
 )
 
 // @title           AuthService API
@@ -21,6 +29,8 @@ import (
 // @securityDefinitions.apikey  JwtBearer
 // @in header
 // @name Authorization
+
+// @securityDefinitions.basic  ClientBasic
 func main() {
 	config, err := services.InitializeConfig()
 	if err != nil {
@@ -33,43 +43,181 @@ func main() {
 		return
 	}
 
-	tokenHandler, err := services.InitTokenHandler(config.SecretKey)
+	phoneParser, err := phone.NewParser(config.Phone.AllowedCountryCodes, config.Phone.DefaultCountryCode)
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Error("Unable to init phone parser: " + err.Error())
+		return
+	}
+
+	// "auth_service migrate down <steps>" rolls back last migrations instead of starting service
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(&config.DbSettings, phoneParser, logger, os.Args[2:])
 		return
 	}
 
-	dbContext, err := database.InitDatabase(&config.DbSettings)
+	storage, dbConnection, err := initStorage(config, phoneParser, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("%v", config))
-		logger.Error("Unable to init database: " + err.Error())
+		// Config itself contains secrets, so only connection parameters are logged
+		logger.Error("Unable to init database: "+err.Error(), config.DbSettings.LogFields()...)
 		return
 	}
 
-	userRepository := repositories.NewUserRepository(dbContext.Connection)
+	keyring, err := services.InitKeyring(config.SecretKey, config.TokenConfig, storage.SigningKeys, logger)
+	if err != nil {
+		logger.Error("Unable to init keyring: " + err.Error())
+		return
+	}
+	go keyring.Start()
+
+	revocationStore := services.NewRevocationStore(storage.RevokedTokens, config.TokenConfig, logger)
+	go revocationStore.StartPruning()
+
+	userStatusChecker := services.NewUserStatusChecker(storage.Users, config.TokenConfig, logger)
+	go userStatusChecker.StartPruning()
 
-	kafkaProducer, err := services.NewKafkaProducer(config.KafkaConfig, logger)
+	roleService, err := services.InitRoleService(storage.Roles, logger)
+	if err != nil {
+		logger.Error("Unable to init roles: " + err.Error())
+		return
+	}
+	go roleService.Start()
+
+	inviteCodeService := services.NewInviteCodeService(storage.InviteCodes)
+
+	tokenHandler, err := services.InitTokenHandler(keyring, revocationStore, userStatusChecker, roleService, config.TokenConfig)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	refreshTokenHandler := services.NewRefreshTokenHandler(storage.RefreshTokens, storage.Transactions, config.TokenConfig)
+
+	accountPurger := services.NewAccountPurger(storage.Users, config.Users, logger)
+	go accountPurger.StartPurging()
+
+	directKafkaProducer, err := initKafkaProducer(config, logger)
 	if err != nil {
 		logger.Error("Unable to init kafka: " + err.Error())
 		return
 	}
 
-	kafkaConsumer, err := services.InitKafkaConsumer(config.KafkaConfig, logger)
+	// Messages are saved to outbox and sent by relay, so requests don't fail when kafka is unavailable
+	kafkaProducer := services.NewOutboxKafkaProducer(storage.Outbox, directKafkaProducer, config.KafkaConfig)
+	outboxRelay := services.NewOutboxRelay(storage.Outbox, directKafkaProducer, config.Outbox, logger)
+	go outboxRelay.Start()
+
+	smsStorage, err := services.InitSmsStorage(config.SmsConfig, config.SecretKey, dbConnection, logger)
+	if err != nil {
+		logger.Error("Unable to init sms storage: " + err.Error())
+		return
+	}
+	go smsStorage.StartCleanup()
+
+	rateLimiter, err := services.InitRateLimiter(config.RateLimits, dbConnection, logger)
+	if err != nil {
+		logger.Error("Unable to init rate limiter: " + err.Error())
+		return
+	}
+	go rateLimiter.StartCleanup()
+
+	smsCodeSender, err := services.InitSmsCodeSender(config.SmsConfig, smsStorage, kafkaProducer)
 	if err != nil {
-		panic("while initing kafka consumer happened error: " + err.Error())
+		logger.Error("Unable to init sms code sender: " + err.Error())
+		return
+	}
+
+	// Codes generated by AuthService don't come back from SmsService
+	if config.SmsConfig.CodeSource == services.SmsCodeSourceSmsService {
+		kafkaConsumer, err := services.InitKafkaConsumer(config.KafkaConfig, config.SmsConfig, smsStorage, phoneParser, logger)
+		if err != nil {
+			panic("while initing kafka consumer happened error: " + err.Error())
+		}
+		go kafkaConsumer.Start()
 	}
-	go kafkaConsumer.Start()
 
 	e := echo.New()
 
+	// Service runs behind ingress, so client ip is taken from X-Forwarded-For
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+
 	// Auth router
-	authRouter := routers.NewAuthRouter(logger, tokenHandler, userRepository, kafkaProducer, kafkaConsumer)
-	e.POST("/api/v1/auth/generate-token", authRouter.GenerateToken)
+	smsVerifier := routers.NewSmsVerifier(logger, smsCodeSender, smsStorage, rateLimiter, config.RateLimits)
+	accountManager := routers.NewAccountManager(logger, storage.Users, tokenHandler, refreshTokenHandler, kafkaProducer, userStatusChecker, config.Users, storage.Transactions)
+	authRouter := routers.NewAuthRouter(logger, tokenHandler, refreshTokenHandler, storage.Users, phoneParser, smsVerifier, roleService, inviteCodeService, storage.Transactions, kafkaProducer, accountManager)
 	e.POST("/api/v1/auth/validate-token", authRouter.ValidateToken)
 
+	// Introspection reveals claims of any token, so only known clients can call it (RFC 7662)
+	introspectionClientSecrets, err := config.TokenConfig.IntrospectionClientSecrets()
+	if err != nil {
+		logger.Error("Unable to read introspection clients: " + err.Error())
+		return
+	}
+
+	if len(introspectionClientSecrets) == 0 {
+		logger.Warn("tokens.introspection_clients is empty, token introspection is rejected for everyone")
+	}
+
+	requireIntrospectionClient := middlewares.RequireClientCredentials(introspectionClientSecrets, logger)
+	e.POST("/api/v1/auth/introspect", authRouter.IntrospectToken, requireIntrospectionClient)
+
+	e.POST("/api/v1/auth/refresh", authRouter.RefreshToken)
+	e.POST("/api/v1/auth/logout", authRouter.Logout)
+	e.POST("/api/v1/auth/revoke", authRouter.RevokeToken)
+
 	e.POST("/api/v1/auth/register", authRouter.Register)
 	e.POST("/api/v1/auth/send-sms-code", authRouter.SendSmsCode)
-	e.POST("/api/v1/auth/verify-sms-code", authRouter.VerifySmsCode)
+
+	// Both ways of checking sms codes share one limit of attempts
+	verifyRateLimit := middlewares.RateLimitByIp(rateLimiter, "verify-sms-code", config.RateLimits.VerifyPerIpPerHour, time.Hour, logger)
+	e.POST("/api/v1/auth/verify-sms-code", authRouter.VerifySmsCode, verifyRateLimit)
+
+	e.POST("/api/v1/auth/login/start", authRouter.LoginStart)
+	e.POST("/api/v1/auth/login/complete", authRouter.LoginComplete, verifyRateLimit)
+
+	// User router
+	requireAuth := middlewares.RequireAuth(tokenHandler, logger)
+	userRouter := routers.NewUserRouter(logger, storage.Users, phoneParser, smsVerifier, tokenHandler, refreshTokenHandler, kafkaProducer, accountManager, storage.Transactions, roleService)
+	e.GET("/api/v1/users/me", userRouter.GetMe, requireAuth)
+	e.PATCH("/api/v1/users/me", userRouter.UpdateMe, requireAuth)
+	e.DELETE("/api/v1/users/me", userRouter.DeleteMe, requireAuth)
+	e.GET("/api/v1/users/me/export", userRouter.ExportMe, requireAuth)
+	e.POST("/api/v1/users/me/phone/start", userRouter.StartPhoneChange, requireAuth)
+	e.POST("/api/v1/users/me/phone/complete", userRouter.CompletePhoneChange, requireAuth, verifyRateLimit)
+
+	// Admin router
+	requireAdmin := middlewares.RequireRole(logger, entities.UserRoleAdmin)
+
+	// Token can be minted only for existing user and only by admin, otherwise anyone could get admin token
+	e.POST("/api/v1/auth/generate-token", authRouter.GenerateToken, requireAuth, requireAdmin)
+	adminRouter := routers.NewAdminRouter(logger, storage.Users, accountManager, roleService, keyring)
+	adminGroup := e.Group("/api/v1/admin", requireAuth, requireAdmin)
+	adminGroup.GET("/users", adminRouter.ListUsers)
+	adminGroup.GET("/users/:id", adminRouter.GetUser)
+	adminGroup.PUT("/users/:id/role", adminRouter.ChangeUserRole)
+	adminGroup.GET("/users/:id/roles", adminRouter.GetUserRoles)
+	adminGroup.PUT("/users/:id/roles", adminRouter.SetUserRoles)
+	adminGroup.POST("/users/:id/suspend", adminRouter.SuspendUser)
+	adminGroup.POST("/users/:id/unsuspend", adminRouter.UnsuspendUser)
+	adminGroup.POST("/users/:id/ban", adminRouter.BanUser)
+	adminGroup.POST("/users/:id/unban", adminRouter.UnbanUser)
+	adminGroup.POST("/users/:id/approve", adminRouter.ApproveUser)
+	adminGroup.DELETE("/users/:id", adminRouter.DeleteUser)
+	adminGroup.POST("/keys/rotate", adminRouter.RotateSigningKey)
+
+	roleRouter := routers.NewRoleRouter(logger, roleService)
+	adminGroup.GET("/roles", roleRouter.ListRoles)
+	adminGroup.POST("/roles", roleRouter.CreateRole)
+	adminGroup.PUT("/roles/:name", roleRouter.UpdateRole)
+	adminGroup.DELETE("/roles/:name", roleRouter.DeleteRole)
+
+	inviteCodeRouter := routers.NewInviteCodeRouter(logger, inviteCodeService, roleService)
+	adminGroup.GET("/invite-codes", inviteCodeRouter.ListInviteCodes)
+	adminGroup.POST("/invite-codes", inviteCodeRouter.CreateInviteCode)
+	adminGroup.DELETE("/invite-codes/:id", inviteCodeRouter.RevokeInviteCode)
+
+	// JWKS router
+	jwksRouter := routers.NewJwksRouter(logger, tokenHandler)
+	e.GET("/.well-known/jwks.json", jwksRouter.GetJwks)
 
 	// Health router
 	healthRouter := routers.NewHealthRouter(logger)
@@ -88,3 +236,49 @@ func main() {
 
 	e.Logger.Fatal(e.Start(":" + config.Port))
 }
+
+// Memory storage keeps all data in process, so service can be run locally without PostgreSQL.
+// Connection is nil then
+func initStorage(config *services.AppConfig, phoneParser phone.Parser, logger *zap.Logger) (*repositories.Storage, *sql.DB, error) {
+	if config.Storage == services.StorageMemory {
+		logger.Warn("Storage is in memory, all data will be lost on restart")
+		return repositories.NewInMemoryStorage(phoneParser), nil, nil
+	}
+
+	dbContext, err := database.InitDatabase(&config.DbSettings, phoneParser, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dbContext.Storage(phoneParser), dbContext.Connection, nil
+}
+
+// With memory storage service runs without kafka too: messages are written to log instead
+func initKafkaProducer(config *services.AppConfig, logger *zap.Logger) (services.KafkaProducer, error) {
+	if config.Storage == services.StorageMemory {
+		return services.NewLoggingKafkaProducer(logger), nil
+	}
+
+	return services.NewKafkaProducer(config.KafkaConfig, logger)
+}
+
+func runMigrateCommand(databaseConfig *services.DatabaseConfig, phoneParser phone.Parser, logger *zap.Logger, args []string) {
+	if len(args) != 2 || args[0] != "down" {
+		logger.Error("Usage: migrate down <steps>")
+		return
+	}
+
+	steps, err := strconv.Atoi(args[1])
+	if err != nil || steps < 1 {
+		logger.Error("Amount of migrations to roll back must be positive number")
+		return
+	}
+
+	err = database.RollbackMigrations(databaseConfig, phoneParser, logger, steps)
+	if err != nil {
+		logger.Error("Unable to roll back migrations: " + err.Error())
+		return
+	}
+
+	logger.Info("Migrations are rolled back")
+}